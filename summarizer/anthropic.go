@@ -0,0 +1,384 @@
+package summarizer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicProvider generates summaries with the Anthropic messages API.
+type anthropicProvider struct {
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// promptCaching marks the prompt with cache_control breakpoints so Anthropic can reuse it across runs.
+var promptCaching bool
+
+// anthropicPromptCachingBeta is the beta header value enabling prompt caching.
+const anthropicPromptCachingBeta = "prompt-caching-2024-07-31"
+
+// anthropicOutputLimits lists the maximum max_tokens value accepted by each model family, matched by prefix.
+// Models not listed here are only checked for a positive value.
+var anthropicOutputLimits = []struct {
+	prefix string
+	limit  int
+}{
+	{"claude-3-5-sonnet", 8192},
+	{"claude-3-5-haiku", 8192},
+	{"claude-3-7-sonnet", 64000},
+	{"claude-3-opus", 4096},
+	{"claude-3-sonnet", 4096},
+	{"claude-3-haiku", 4096},
+	{"claude-sonnet-4", 64000},
+	{"claude-opus-4", 32000},
+}
+
+// validateMaxTokens checks that maxTokens is positive and within the output limit of the model.
+func validateMaxTokens(model string, maxTokens int) error {
+	if maxTokens <= 0 {
+		return fmt.Errorf("-max-tokens must be positive")
+	}
+	for _, l := range anthropicOutputLimits {
+		if strings.HasPrefix(model, l.prefix) && maxTokens > l.limit {
+			return fmt.Errorf("-max-tokens %d exceeds the %d token output limit of %s", maxTokens, l.limit, model)
+		}
+	}
+	return nil
+}
+
+// anthropicErrorEnvelope is the body the messages API sends with error responses.
+type anthropicErrorEnvelope struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicErrorHints suggests what to do about the most common Anthropic error types.
+var anthropicErrorHints = map[string]string{
+	"authentication_error":  "check ANTHROPIC_API_KEY",
+	"permission_error":      "the API key has no access to this model or resource",
+	"not_found_error":       "check the -model name",
+	"rate_limit_error":      "rate limited, wait a moment and try again",
+	"invalid_request_error": "check -model and -max-tokens",
+	"request_too_large":     "the prompt is too large, try -on-overflow truncate or -exclude",
+	"overloaded_error":      "the Anthropic API is temporarily overloaded, try again later",
+}
+
+// anthropicError is an error reported by the Anthropic API in its error envelope.
+type anthropicError struct {
+	statusCode int
+	errType    string
+	message    string
+}
+
+func (e *anthropicError) Error() string {
+	msg := fmt.Sprintf("error from the Anthropic API: %s: %s", e.errType, e.message)
+	if hint := anthropicErrorHints[e.errType]; hint != "" {
+		msg += " (" + hint + ")"
+	}
+	return msg
+}
+
+// Unwrap returns ErrProviderAuth or ErrProviderRateLimited for the error types that mean them.
+func (e *anthropicError) Unwrap() error {
+	switch e.errType {
+	case "authentication_error", "permission_error":
+		return ErrProviderAuth
+	case "rate_limit_error":
+		return ErrProviderRateLimited
+	}
+	return nil
+}
+
+// parseAnthropicError returns the error described by an Anthropic error envelope, or nil if body isn't one.
+func parseAnthropicError(statusCode int, body []byte) error {
+	var envelope anthropicErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Type != "error" || envelope.Error.Type == "" {
+		return nil
+	}
+	return &anthropicError{statusCode: statusCode, errType: envelope.Error.Type, message: envelope.Error.Message}
+}
+
+// anthropicStatusError replaces a non-2xx status error with the error from its Anthropic envelope, if any.
+func anthropicStatusError(err error) error {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		if apiErr := parseAnthropicError(statusErr.statusCode, statusErr.body); apiErr != nil {
+			return apiErr
+		}
+	}
+	return err
+}
+
+// requestBody builds the messages API request body for the prompt.
+func (p *anthropicProvider) requestBody(prompt string, stream bool) ([]byte, error) {
+	return p.conversationBody([]chatMessage{{Role: "user", Content: prompt}}, stream)
+}
+
+// conversationBody builds the messages API request body for a conversation that starts with the prompt.
+func (p *anthropicProvider) conversationBody(messages []chatMessage, stream bool) ([]byte, error) {
+	requestBody, err := json.Marshal(p.conversationRequest(messages, stream))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+	return requestBody, nil
+}
+
+// conversationRequest returns the fields of the messages API request for a conversation.
+func (p *anthropicProvider) conversationRequest(messages []chatMessage, stream bool) map[string]interface{} {
+	turns := make([]map[string]interface{}, len(messages))
+	for i, message := range messages {
+		var content interface{} = message.Content
+		if i == 0 {
+			content = promptContent(message.Content)
+		}
+		turns[i] = map[string]interface{}{"role": message.Role, "content": content}
+	}
+	body := map[string]interface{}{
+		"model":      p.model,
+		"messages":   turns,
+		"max_tokens": p.maxTokens,
+	}
+	if systemPrompt != "" {
+		body["system"] = systemPrompt
+	}
+	addSamplingParams(body, "temperature", "top_p")
+	if stream {
+		body["stream"] = true
+	}
+	return body
+}
+
+// promptContent returns the user message content for the prompt. With prompt caching the static
+// instructions before the cache breakpoint become their own cached block; without a breakpoint
+// the whole prompt is cached, which pays off when the same changes are summarized again.
+func promptContent(prompt string) interface{} {
+	if !promptCaching {
+		return prompt
+	}
+
+	cacheControl := map[string]string{"type": "ephemeral"}
+	prefix, rest, ok := splitCacheBreakpoint(prompt)
+	if !ok || strings.TrimSpace(prefix) == "" {
+		return []map[string]interface{}{
+			{"type": "text", "text": stripCacheBreakpoint(prompt), "cache_control": cacheControl},
+		}
+	}
+	return []map[string]interface{}{
+		{"type": "text", "text": prefix, "cache_control": cacheControl},
+		{"type": "text", "text": rest},
+	}
+}
+
+// anthropicUsage is the token accounting in messages API responses.
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// logUsage prints the token usage of a response in verbose mode.
+func (u anthropicUsage) logUsage() {
+	logf("Anthropic usage: %d input tokens, %d output tokens, %d written to cache, %d read from cache",
+		u.InputTokens, u.OutputTokens, u.CacheCreationInputTokens, u.CacheReadInputTokens)
+}
+
+// newRequest creates an authenticated request to the messages API.
+func (p *anthropicProvider) newRequest(ctx context.Context, requestBody []byte) (*http.Request, error) {
+	req, err := newJSONRequest(ctx, anthropicAPIURL, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	if promptCaching {
+		req.Header.Set("anthropic-beta", anthropicPromptCachingBeta)
+	}
+	setCustomHeaders(req)
+	return req, nil
+}
+
+// Summarize sends the prompt to the Anthropic messages API and returns the generated text.
+func (p *anthropicProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	requestBody, err := p.requestBody(prompt, false)
+	if err != nil {
+		return "", err
+	}
+	return p.send(ctx, requestBody)
+}
+
+// Converse sends the conversation to the Anthropic messages API and returns the reply to its last message.
+func (p *anthropicProvider) Converse(ctx context.Context, messages []chatMessage) (string, error) {
+	requestBody, err := p.conversationBody(messages, false)
+	if err != nil {
+		return "", err
+	}
+	return p.send(ctx, requestBody)
+}
+
+// SummarizeStructured makes the model call a tool whose input schema is structuredSchema and returns
+// the tool input, which the API guarantees to be a JSON object.
+func (p *anthropicProvider) SummarizeStructured(ctx context.Context, prompt string) (string, error) {
+	request := p.conversationRequest([]chatMessage{{Role: "user", Content: prompt}}, false)
+	request["tools"] = []map[string]interface{}{{
+		"name":         structuredSchemaName,
+		"description":  "Submit the pull request summary.",
+		"input_schema": structuredSchema,
+	}}
+	request["tool_choice"] = map[string]string{"type": "tool", "name": structuredSchemaName}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+	body, err := p.post(ctx, requestBody)
+	if err != nil {
+		return "", err
+	}
+	return decodeAnthropicToolInput(body, structuredSchemaName)
+}
+
+// send posts a non-streaming request body to the messages API and returns the generated text.
+func (p *anthropicProvider) send(ctx context.Context, requestBody []byte) (string, error) {
+	body, err := p.post(ctx, requestBody)
+	if err != nil {
+		return "", err
+	}
+	return decodeAnthropicResponse(body)
+}
+
+// post posts a non-streaming request body to the messages API and returns the response body.
+func (p *anthropicProvider) post(ctx context.Context, requestBody []byte) ([]byte, error) {
+	_, body, err := doWithRetry(ctx, "Anthropic API", func() (*http.Request, error) {
+		return p.newRequest(ctx, requestBody)
+	})
+	if err != nil {
+		return nil, anthropicStatusError(err)
+	}
+
+	if verbose {
+		var result struct {
+			Usage anthropicUsage `json:"usage"`
+		}
+		if json.Unmarshal(body, &result) == nil {
+			result.Usage.logUsage()
+		}
+	}
+	recordResponseUsage(body)
+	return body, nil
+}
+
+// SummarizeStream sends the prompt with streaming enabled and writes the text deltas to w as they arrive.
+// If the server answers with a regular JSON response instead of an event stream, the full text is written at once.
+func (p *anthropicProvider) SummarizeStream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	requestBody, err := p.requestBody(prompt, true)
+	if err != nil {
+		return "", err
+	}
+
+	// The stream is retried until its status arrives, but not once text was written to w
+	var start time.Time
+	resp, err := doStreamWithRetry(ctx, "Anthropic API", func() (*http.Request, error) {
+		req, err := p.newRequest(ctx, requestBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		start = time.Now()
+		return req, nil
+	})
+	if err != nil {
+		return "", anthropicStatusError(err)
+	}
+	defer resp.Body.Close()
+
+	// Keep a copy of the raw event stream for -log-file
+	if apiLog != nil {
+		var received bytes.Buffer
+		resp.Body = io.NopCloser(io.TeeReader(resp.Body, &received))
+		defer func() {
+			apiLog.record("Anthropic API", resp.Request, start, resp.StatusCode, received.Bytes(), true, nil)
+		}()
+	}
+	defer func() { logf("Anthropic API: stream finished in %s", time.Since(start).Round(time.Millisecond)) }()
+
+	// Fall back to a regular response when the server doesn't stream
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("error reading response: %v", err)
+		}
+		summary, err := decodeAnthropicResponse(body)
+		if err != nil {
+			return "", err
+		}
+		recordResponseUsage(body)
+		fmt.Fprint(w, summary)
+		return summary, nil
+	}
+
+	return readAnthropicStream(resp.Body, w)
+}
+
+// readAnthropicStream consumes a messages API server-sent event stream, writing text deltas to w.
+// It returns the accumulated text once a message_stop event arrives.
+func readAnthropicStream(r io.Reader, w io.Writer) (string, error) {
+	var summary strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var event struct {
+			anthropicErrorEnvelope
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			// message_delta events carry the output tokens
+			Usage anthropicUsage `json:"usage"`
+		}
+		if err := unmarshalResponse("Anthropic", []byte(strings.TrimSpace(data)), &event); err != nil {
+			return summary.String(), err
+		}
+
+		switch event.Type {
+		case "message_start":
+			event.Message.Usage.logUsage()
+			metrics.addUsage(event.Message.Usage.InputTokens, 0)
+		case "message_delta":
+			metrics.addUsage(0, event.Usage.OutputTokens)
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				summary.WriteString(event.Delta.Text)
+				fmt.Fprint(w, event.Delta.Text)
+			}
+		case "message_stop":
+			return summary.String(), nil
+		case "error":
+			return summary.String(), &anthropicError{errType: event.Error.Type, message: event.Error.Message}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return summary.String(), fmt.Errorf("error reading Anthropic stream: %v", err)
+	}
+	return summary.String(), errors.New("the Anthropic stream ended without message_stop")
+}
@@ -0,0 +1,71 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "success", status: http.StatusOK, body: `{"type":"message","content":[{"type":"text","text":"a summary"}]}`, want: "a summary"},
+		{name: "non-2xx status", status: http.StatusUnauthorized, body: `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, wantErr: "authentication_error: invalid x-api-key"},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, wantErr: "rate_limit_error: slow down"},
+		{name: "invalid request", status: http.StatusBadRequest, body: `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: too large"}}`, wantErr: "invalid_request_error: max_tokens: too large"},
+		{name: "error envelope with 200", status: http.StatusOK, body: `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, wantErr: "overloaded_error: Overloaded"},
+		{name: "non-2xx without envelope", status: http.StatusBadGateway, body: `bad gateway`, wantErr: "status 502"},
+		{name: "malformed JSON", status: http.StatusOK, body: `{"content":[`, wantErr: "unexpected Anthropic response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &anthropicAPIURL, tt.status, tt.body)
+
+			provider := &anthropicProvider{apiKey: "test-key", model: anthropicModel, maxTokens: anthropicMaxTokens}
+			got, err := provider.Summarize(context.Background(), "prompt")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Summarize() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Summarize() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestBodyPromptCaching(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{name: "with breakpoint", prompt: "instructions" + cacheBreakpointMarker + "diff", want: `[{"cache_control":{"type":"ephemeral"},"text":"instructions","type":"text"},{"text":"diff","type":"text"}]`},
+		{name: "without breakpoint", prompt: "diff", want: `[{"cache_control":{"type":"ephemeral"},"text":"diff","type":"text"}]`},
+	}
+
+	promptCaching = true
+	defer func() { promptCaching = false }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &anthropicProvider{model: anthropicModel, maxTokens: anthropicMaxTokens}
+			body, err := provider.requestBody(tt.prompt, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(body), `"content":`+tt.want) {
+				t.Fatalf("requestBody() = %s, want content %s", body, tt.want)
+			}
+		})
+	}
+}
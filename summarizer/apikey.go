@@ -0,0 +1,118 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// -api-key and -api-key-file set the API key of the -provider. They have no config file keys on
+// purpose: a repository's .prgpt.toml must not be able to run commands or read files as secrets.
+var apiKeyFlag, apiKeyFileFlag string
+
+// apiKeyProvider is the provider -api-key and -api-key-file apply to.
+var apiKeyProvider string
+
+// apiKeyEnvs are the environment variables holding the API key of each provider. The same name
+// with a _FILE suffix, e.g. ANTHROPIC_API_KEY_FILE, names a file holding the key.
+var apiKeyEnvs = map[string]string{
+	"anthropic": "ANTHROPIC_API_KEY",
+	"openai":    "OPENAI_API_KEY",
+	"azure":     "AZURE_OPENAI_KEY",
+	"gemini":    "GEMINI_API_KEY",
+}
+
+// apiKeyVar returns the variable holding the API key of provider, or nil if it doesn't use one.
+func apiKeyVar(provider string) *string {
+	switch provider {
+	case "anthropic":
+		return &anthropicAPIKey
+	case "openai":
+		return &openAIAPIKey
+	case "azure":
+		return &azureAPIKey
+	case "gemini":
+		return &geminiAPIKey
+	}
+	return nil
+}
+
+// resolvedAPIKeys records the providers whose key was resolved, so a cmd: reference runs only once.
+var resolvedAPIKeys = map[string]bool{}
+
+// resolveAPIKey sets the API key of provider from the first source that is given: -api-key,
+// -api-key-file, then the file named by the <env>_FILE variable. Without any of them the key of
+// the environment variable is kept. The key itself is never logged.
+func resolveAPIKey(provider string) error {
+	key := apiKeyVar(provider)
+	if key == nil || resolvedAPIKeys[provider] {
+		return nil
+	}
+	resolvedAPIKeys[provider] = true
+
+	env := apiKeyEnvs[provider]
+	var value, source string
+	var err error
+	switch fileEnv := env + "_FILE"; {
+	case provider == apiKeyProvider && apiKeyFlag != "":
+		value, err = resolveSecret(apiKeyFlag)
+		source = "-api-key"
+	case provider == apiKeyProvider && apiKeyFileFlag != "":
+		value, err = readSecretFile(apiKeyFileFlag)
+		source = "-api-key-file"
+	case os.Getenv(fileEnv) != "":
+		value, err = readSecretFile(os.Getenv(fileEnv))
+		source = fileEnv
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", source, err)
+	}
+	if value == "" {
+		return fmt.Errorf("%s: the API key is empty", source)
+	}
+	logf("Using the %s API key from %s", provider, source)
+	*key = value
+	return nil
+}
+
+// resolveSecret returns the secret an -api-key value refers to: the trimmed content of the file
+// of a "file:path" reference, the trimmed output of the command of a "cmd:command" reference, such
+// as "cmd:op read op://vault/item/key", and the value itself otherwise.
+func resolveSecret(ref string) (string, error) {
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		return readSecretFile(path)
+	}
+	if command, ok := strings.CutPrefix(ref, "cmd:"); ok {
+		return secretCommandOutput(command)
+	}
+	return ref, nil
+}
+
+// readSecretFile returns the content of the file at path without surrounding whitespace.
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// secretCommandOutput runs command with the shell and returns its trimmed output. Errors include
+// the command's stderr but never its output, which may be a partial secret.
+func secretCommandOutput(command string) (string, error) {
+	cmd := shellCommand(command)
+	cmd.Stdin = os.Stdin // secret managers may ask to unlock
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%q failed: %v: %s", command, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("error running %q: %v", command, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
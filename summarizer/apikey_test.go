@@ -0,0 +1,72 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("  sk-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "sk-plain", want: "sk-plain"},
+		{ref: "file:" + path, want: "sk-from-file"},
+		{ref: "file:" + path + ".missing", wantErr: true},
+		{ref: "cmd:echo sk-from-command", want: "sk-from-command"},
+		{ref: "cmd:exit 3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolveSecret(tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveSecret(%q) = %q, %v, want %q, error %v", tt.ref, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestResolveAPIKeyPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	flagFile, envFile := filepath.Join(dir, "flag-key"), filepath.Join(dir, "env-key")
+	os.WriteFile(flagFile, []byte("key-from-flag-file\n"), 0o600)
+	os.WriteFile(envFile, []byte("key-from-env-file\n"), 0o600)
+
+	original := anthropicAPIKey
+	defer func() {
+		anthropicAPIKey, apiKeyFlag, apiKeyFileFlag, apiKeyProvider = original, "", "", ""
+		resolvedAPIKeys = map[string]bool{}
+	}()
+
+	tests := []struct {
+		name    string
+		flag    string
+		file    string
+		envFile string
+		want    string
+	}{
+		{name: "environment key", want: "key-from-env"},
+		{name: "env key file", envFile: envFile, want: "key-from-env-file"},
+		{name: "key file flag", file: flagFile, envFile: envFile, want: "key-from-flag-file"},
+		{name: "explicit key", flag: "key-from-flag", file: flagFile, envFile: envFile, want: "key-from-flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANTHROPIC_API_KEY_FILE", tt.envFile)
+			anthropicAPIKey, apiKeyFlag, apiKeyFileFlag, apiKeyProvider = "key-from-env", tt.flag, tt.file, "anthropic"
+			resolvedAPIKeys = map[string]bool{}
+
+			if err := resolveAPIKey("anthropic"); err != nil {
+				t.Fatalf("resolveAPIKey() error = %v", err)
+			}
+			if anthropicAPIKey != tt.want {
+				t.Errorf("API key = %q, want %q", anthropicAPIKey, tt.want)
+			}
+		})
+	}
+}
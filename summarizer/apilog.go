@@ -0,0 +1,113 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// apiLog records every API call as a JSON line when -log-file is set. It is nil otherwise.
+var apiLog *apiLogger
+
+// apiLogger appends API call records to a log file. Request headers are never logged,
+// so API keys sent in headers can't end up in the file.
+type apiLogger struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// apiLogEntry is a single API call in the log file.
+type apiLogEntry struct {
+	Time         string `json:"time"`
+	API          string `json:"api"`
+	Method       string `json:"method"`
+	URL          string `json:"url"`
+	RequestBody  string `json:"requestBody"`
+	Status       int    `json:"status,omitempty"`
+	ResponseBody string `json:"responseBody,omitempty"`
+	Streamed     bool   `json:"streamed,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMS   int64  `json:"durationMs"`
+}
+
+// openAPILog opens path for appending API call records.
+func openAPILog(path string) (*apiLogger, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %v", err)
+	}
+	return &apiLogger{f: f}, nil
+}
+
+// record appends an entry for a request and its outcome. Failing to write the log only prints a warning.
+func (l *apiLogger) record(api string, req *http.Request, start time.Time, status int, body []byte, streamed bool, callErr error) {
+	if l == nil {
+		return
+	}
+
+	entry := apiLogEntry{
+		Time:         start.UTC().Format(time.RFC3339Nano),
+		API:          api,
+		Method:       req.Method,
+		URL:          redactURL(req),
+		RequestBody:  requestBodyString(req),
+		Status:       status,
+		ResponseBody: string(body),
+		Streamed:     streamed,
+		DurationMS:   time.Since(start).Milliseconds(),
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		warnf("Warning: could not encode log entry: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		warnf("Warning: could not write log file: %v", err)
+	}
+}
+
+// Close closes the log file.
+func (l *apiLogger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// redactURL returns the request URL with key query parameters masked.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	for _, name := range []string{"key", "api_key", "api-key"} {
+		if query.Has(name) {
+			query.Set(name, maskSecret(query.Get(name)))
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// requestBodyString returns a copy of the request body without consuming it.
+func requestBodyString(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	content, _ := io.ReadAll(body)
+	return string(content)
+}
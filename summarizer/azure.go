@@ -0,0 +1,32 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Azure OpenAI settings used with -provider azure.
+var (
+	azureEndpoint   = os.Getenv("AZURE_OPENAI_ENDPOINT")
+	azureAPIKey     = os.Getenv("AZURE_OPENAI_KEY")
+	azureDeployment = ""
+	azureAPIVersion = "2024-06-01"
+)
+
+// azureChatURL returns the chat completions URL of an Azure OpenAI deployment.
+func azureChatURL(endpoint, deployment, apiVersion string) (string, error) {
+	if endpoint == "" {
+		return "", errors.New("AZURE_OPENAI_ENDPOINT is not set")
+	}
+	if deployment == "" {
+		return "", errors.New("-deployment is required with -provider azure")
+	}
+	if apiVersion == "" {
+		return "", errors.New("-api-version must not be empty")
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(deployment), url.QueryEscape(apiVersion)), nil
+}
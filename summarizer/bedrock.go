@@ -0,0 +1,87 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// AWS Bedrock settings used with -provider bedrock.
+var (
+	bedrockModel  = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+	bedrockRegion = envOr("AWS_REGION", os.Getenv("AWS_DEFAULT_REGION"))
+)
+
+// bedrockAPIURL is the bedrock-runtime endpoint; {region} is replaced with the AWS region.
+var bedrockAPIURL = "https://bedrock-runtime.{region}.amazonaws.com"
+
+// bedrockAnthropicVersion is the anthropic_version Bedrock expects in Claude request bodies.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// bedrockProvider generates summaries with an Anthropic Claude model on AWS Bedrock, calling the
+// bedrock-runtime InvokeModel API with SigV4 signed requests.
+type bedrockProvider struct {
+	region    string
+	creds     awsCredentials
+	model     string
+	maxTokens int
+}
+
+// bedrockClaudeModel returns the Claude model name in a Bedrock model ID such as
+// "us.anthropic.claude-3-5-sonnet-20240620-v1:0", for checking Anthropic model limits.
+func bedrockClaudeModel(modelID string) string {
+	if _, name, ok := strings.Cut(modelID, "anthropic."); ok {
+		return name
+	}
+	return modelID
+}
+
+// invokeURL returns the InvokeModel URL of the model. The model ID is escaped like the AWS SDKs do,
+// including the ":" before its version.
+func (p *bedrockProvider) invokeURL() string {
+	base := strings.ReplaceAll(bedrockAPIURL, "{region}", url.PathEscape(p.region))
+	model := strings.ReplaceAll(url.PathEscape(p.model), ":", "%3A")
+	return strings.TrimSuffix(base, "/") + "/model/" + model + "/invoke"
+}
+
+// Summarize sends the prompt to Bedrock in the Anthropic messages format and returns the generated text.
+func (p *bedrockProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	body := map[string]interface{}{
+		"anthropic_version": bedrockAnthropicVersion,
+		"max_tokens":        p.maxTokens,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": prompt},
+		},
+	}
+	if systemPrompt != "" {
+		body["system"] = systemPrompt
+	}
+	addSamplingParams(body, "temperature", "top_p")
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	_, respBody, err := doWithRetry(ctx, "Bedrock API", func() (*http.Request, error) {
+		req, err := newJSONRequest(ctx, p.invokeURL(), requestBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		setCustomHeaders(req)
+		// Sign every attempt anew, the signature is only valid for a few minutes
+		signV4(req, requestBody, p.creds, p.region, "bedrock", time.Now())
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	recordResponseUsage(respBody)
+	return decodeAnthropicResponse(respBody)
+}
@@ -0,0 +1,67 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBedrockSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"type":"message","content":[{"type":"text","text":"a summary"}]}`, want: "a summary"},
+		{name: "non-2xx status", status: http.StatusForbidden, body: `{"message":"The security token included in the request is invalid."}`, wantErr: true},
+		{name: "malformed JSON", status: http.StatusOK, body: `{"content":[`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &bedrockAPIURL, tt.status, tt.body)
+
+			provider := &bedrockProvider{
+				region:    "us-east-1",
+				creds:     awsCredentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "secret"},
+				model:     bedrockModel,
+				maxTokens: anthropicMaxTokens,
+			}
+			got, err := provider.Summarize(context.Background(), "prompt")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Summarize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSignV4 checks the signer against the get-vanilla case of the AWS SigV4 test suite.
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := awsCredentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestBedrockInvokeURL(t *testing.T) {
+	provider := &bedrockProvider{region: "eu-west-1", model: "anthropic.claude-3-5-sonnet-20240620-v1:0"}
+	got := provider.invokeURL()
+	want := "https://bedrock-runtime.eu-west-1.amazonaws.com/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke"
+	if got != want {
+		t.Fatalf("invokeURL() = %q, want %q", got, want)
+	}
+}
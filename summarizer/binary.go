@@ -0,0 +1,107 @@
+package summarizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// base85Line matches a data line of a "GIT binary patch" block: a length character followed by base85 data.
+var base85Line = regexp.MustCompile("^[A-Za-z][0-9A-Za-z!#$%&()*+\\-;<=>?@^_`{|}~]+$")
+
+// binaryNote is the line a binary file change is collapsed to.
+func binaryNote(path string) string {
+	return fmt.Sprintf("<binary file changed: %s>\n", path)
+}
+
+// collapseBinaryDiffs replaces the "Binary files ... differ" marker and any "GIT binary patch" data of
+// every binary file in a unified diff with a one-line note. A binary file that appears several times,
+// e.g. in a patch with multiple commits, is only noted once. It returns the diff and the binary paths.
+func collapseBinaryDiffs(diff string) (string, []string) {
+	var out strings.Builder
+	var paths []string
+	seen := map[string]bool{}
+	for _, chunk := range splitDiffByFile(diff) {
+		header, rest, ok := cutBinaryContent(chunk)
+		if !ok {
+			out.WriteString(chunk)
+			continue
+		}
+		path := diffFilePath(chunk)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+			out.WriteString(header)
+			out.WriteString(binaryNote(path))
+		}
+		out.WriteString(rest)
+	}
+	collapsed := out.String()
+	if !strings.HasSuffix(diff, "\n") {
+		collapsed = strings.TrimSuffix(collapsed, "\n")
+	}
+	return collapsed, paths
+}
+
+// cutBinaryContent splits a single-file diff chunk of a binary file into the file header before the
+// binary marker and whatever follows the binary content, such as the next commit of a patch file.
+// It reports false for text files.
+func cutBinaryContent(chunk string) (header, rest string, ok bool) {
+	if !strings.HasPrefix(chunk, "diff --git ") {
+		return "", "", false
+	}
+	lines := strings.SplitAfter(chunk, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(trimmed, "@@"):
+			// Hunks only appear in text diffs
+			return "", "", false
+		case strings.HasPrefix(trimmed, "Binary files ") && strings.HasSuffix(trimmed, " differ"):
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], ""), true
+		case trimmed == "GIT binary patch":
+			end := i + 1
+			for end < len(lines) && isBinaryPatchLine(strings.TrimRight(lines[end], "\n")) {
+				end++
+			}
+			return strings.Join(lines[:i], ""), strings.Join(lines[end:], ""), true
+		}
+	}
+	return "", "", false
+}
+
+// isBinaryPatchLine reports whether line belongs to the literal or delta blocks of a "GIT binary patch".
+func isBinaryPatchLine(line string) bool {
+	return line == "" || strings.HasPrefix(line, "literal ") || strings.HasPrefix(line, "delta ") || base85Line.MatchString(line)
+}
+
+// binaryOverviewNote is appended to the changes overview to report how many binary files changed.
+func binaryOverviewNote(count int) string {
+	if count == 1 {
+		return "\n 1 binary file changed"
+	}
+	return fmt.Sprintf("\n %d binary files changed", count)
+}
+
+// collapseBinaryFiles collapses the binary file changes in the diff of changes and reports their
+// number in the overview. Binary files are also flagged in the file statistics.
+func collapseBinaryFiles(changes ChangeSet) ChangeSet {
+	diff, paths := collapseBinaryDiffs(changes.Diff)
+	if len(paths) == 0 {
+		return changes
+	}
+	logf("Collapsed %d binary files in the diff", len(paths))
+	changes.Diff = diff
+
+	binary := map[string]bool{}
+	for _, path := range paths {
+		binary[path] = true
+	}
+	for i := range changes.Files {
+		if binary[changes.Files[i].Path] {
+			changes.Files[i].Binary = true
+		}
+	}
+	changes.Overview += binaryOverviewNote(len(paths))
+	return changes
+}
@@ -0,0 +1,97 @@
+package summarizer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollapseBinaryDiffs(t *testing.T) {
+	text := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+	marker := "diff --git a/logo.png b/logo.png\nindex 1111111..2222222 100644\nBinary files a/logo.png and b/logo.png differ\n"
+	patch := "diff --git a/font.woff b/font.woff\nnew file mode 100644\nindex 0000000..3333333\nGIT binary patch\n" +
+		"literal 12\nTcmZ?wbhEHbWMp7q_|L!q0E%%a\n\nliteral 0\nHcmV?d00001\n\n"
+
+	tests := []struct {
+		name      string
+		diff      string
+		want      string
+		wantPaths []string
+	}{
+		{name: "text only", diff: text, want: text},
+		{
+			name:      "binary marker",
+			diff:      text + marker,
+			want:      text + "diff --git a/logo.png b/logo.png\nindex 1111111..2222222 100644\n<binary file changed: logo.png>\n",
+			wantPaths: []string{"logo.png"},
+		},
+		{
+			name:      "GIT binary patch block",
+			diff:      patch + text,
+			want:      "diff --git a/font.woff b/font.woff\nnew file mode 100644\nindex 0000000..3333333\n<binary file changed: font.woff>\n" + text,
+			wantPaths: []string{"font.woff"},
+		},
+		{
+			name: "duplicate path across commits",
+			diff: marker + "From 1234567 Mon Sep 17 00:00:00 2001\nSubject: [PATCH 2/2] Update logo\n\n" + marker,
+			want: "diff --git a/logo.png b/logo.png\nindex 1111111..2222222 100644\n<binary file changed: logo.png>\n" +
+				"From 1234567 Mon Sep 17 00:00:00 2001\nSubject: [PATCH 2/2] Update logo\n\n",
+			wantPaths: []string{"logo.png"},
+		},
+		{
+			name:      "no trailing newline",
+			diff:      "diff --git a/a.bin b/a.bin\nBinary files a/a.bin and b/a.bin differ",
+			want:      "diff --git a/a.bin b/a.bin\n<binary file changed: a.bin>",
+			wantPaths: []string{"a.bin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, paths := collapseBinaryDiffs(tt.diff)
+			if got != tt.want {
+				t.Errorf("collapseBinaryDiffs() diff = %q\nwant %q", got, tt.want)
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("collapseBinaryDiffs() paths = %q, want %q", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestCutBinaryContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunk      string
+		wantHeader string
+		wantRest   string
+		wantOK     bool
+	}{
+		{
+			name:  "text file",
+			chunk: "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-Binary files x differ\n+y\n",
+		},
+		{
+			name:       "binary marker",
+			chunk:      "diff --git a/a.png b/a.png\nBinary files /dev/null and b/a.png differ\n",
+			wantHeader: "diff --git a/a.png b/a.png\n",
+			wantOK:     true,
+		},
+		{
+			name:       "binary patch followed by the next commit",
+			chunk:      "diff --git a/a.png b/a.png\nGIT binary patch\nliteral 5\nMcmZ?wbhEHb\n\n-- \n2.40.0\n",
+			wantHeader: "diff --git a/a.png b/a.png\n",
+			wantRest:   "-- \n2.40.0\n",
+			wantOK:     true,
+		},
+		{name: "not a git chunk", chunk: "Binary files a/a.png and b/a.png differ\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, rest, ok := cutBinaryContent(tt.chunk)
+			if header != tt.wantHeader || rest != tt.wantRest || ok != tt.wantOK {
+				t.Errorf("cutBinaryContent() = %q, %q, %v, want %q, %q, %v", header, rest, ok, tt.wantHeader, tt.wantRest, tt.wantOK)
+			}
+		})
+	}
+}
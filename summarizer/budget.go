@@ -0,0 +1,149 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxInputTokens is the estimated prompt size above which onOverflow kicks in. Zero disables the check.
+var maxInputTokens = 100000
+
+// onOverflow selects what happens to a prompt over budget: "warn", "truncate" or "abort".
+var onOverflow = "warn"
+
+// estimateTokens roughly estimates the number of tokens in s using the four characters per token heuristic.
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// truncateDiff cuts diff down to at most maxChars, ending on a line boundary, or on a rune boundary
+// within a single line, and notes the truncation.
+func truncateDiff(diff string, maxChars int) string {
+	if len(diff) <= maxChars {
+		return diff
+	}
+	if maxChars < 0 {
+		maxChars = 0
+	}
+
+	cut := diff[:runeBoundary(diff, maxChars)]
+	if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+		cut = cut[:i+1]
+	}
+	return cut + fmt.Sprintf("... (diff truncated, %d characters omitted to fit the token budget)", len(diff)-len(cut))
+}
+
+// checkTokenBudget applies onOverflow to a prompt of the given size, sent along with the system
+// prompt and the context files and stack hint added to it. It returns the number of diff characters
+// to drop to fit the budget, which is only non-zero in truncate mode.
+func checkTokenBudget(prompt string) (int, error) {
+	tokens := estimateTokens(prompt) + estimateTokens(systemPrompt)
+	logf("Estimated prompt size: %d tokens", tokens)
+	if maxInputTokens <= 0 || tokens <= maxInputTokens {
+		return 0, nil
+	}
+
+	switch onOverflow {
+	case "abort":
+		return 0, fmt.Errorf("prompt is about %d tokens, over the -max-input-tokens budget of %d", tokens, maxInputTokens)
+	case "truncate":
+		logf("Prompt is about %d tokens, truncating the diff to fit the budget of %d", tokens, maxInputTokens)
+		return len(prompt) + len(systemPrompt) - maxInputTokens*4, nil
+	default:
+		warnf("Warning: prompt is about %d tokens, over the -max-input-tokens budget of %d", tokens, maxInputTokens)
+		return 0, nil
+	}
+}
+
+// maxLinesPerFile caps the changed lines of each file in the diff. Zero disables the cap.
+var maxLinesPerFile int
+
+// limitFileLines keeps the first maxLines changed lines of every file in diff and replaces the rest
+// of its hunks with a note, so one huge generated file can't crowd out all the others. A file that
+// changes in several commits of a log diff shares its allowance between them. It returns the diff
+// and the number of files that were cut.
+func limitFileLines(diff string, maxLines int) (string, int) {
+	if maxLines <= 0 {
+		return diff, 0
+	}
+	var out strings.Builder
+	seen := map[string]int{}
+	cut := map[string]bool{}
+	for _, chunk := range splitDiffByFile(diff) {
+		if !strings.HasPrefix(chunk, "diff --git ") {
+			out.WriteString(chunk)
+			continue
+		}
+		path := diffFilePath(chunk)
+		var file strings.Builder
+		var inHunks bool
+		var omitted, total int
+		for _, line := range strings.SplitAfter(chunk, "\n") {
+			if strings.HasPrefix(line, "@@") {
+				inHunks = true
+			}
+			if !inHunks || !isHunkLine(line) {
+				// Whatever follows the hunks, like the header of the next commit, is kept
+				inHunks = false
+				writeOmittedNote(&file, omitted)
+				total, omitted = total+omitted, 0
+				file.WriteString(line)
+				continue
+			}
+			changed := isChangedLine(line)
+			if seen[path] >= maxLines {
+				if changed {
+					omitted++
+				}
+				continue
+			}
+			if changed {
+				seen[path]++
+			}
+			file.WriteString(line)
+		}
+		if omitted > 0 && !strings.HasSuffix(file.String(), "\n") {
+			file.WriteString("\n")
+		}
+		writeOmittedNote(&file, omitted)
+		if total+omitted > 0 {
+			cut[path] = true
+		}
+		out.WriteString(file.String())
+	}
+
+	limited := out.String()
+	if !strings.HasSuffix(diff, "\n") {
+		limited = strings.TrimSuffix(limited, "\n")
+	}
+	return limited, len(cut)
+}
+
+// isHunkLine reports whether line belongs to a hunk: a hunk header, a context, added or removed line,
+// or a "\ No newline at end of file" marker.
+func isHunkLine(line string) bool {
+	return line != "" && strings.ContainsRune("@ +-\\", rune(line[0]))
+}
+
+// isChangedLine reports whether a hunk line is an added or removed line.
+func isChangedLine(line string) bool {
+	return strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")
+}
+
+// writeOmittedNote notes the number of changed lines cut from a file, if any.
+func writeOmittedNote(out *strings.Builder, omitted int) {
+	if omitted > 0 {
+		fmt.Fprintf(out, "... (%d more changed lines truncated)\n", omitted)
+	}
+}
+
+// limitChangedLines applies -max-lines-per-file to the diff of changes. The file statistics and
+// overview keep the full numbers, so the summary provider still sees how large each change was.
+func limitChangedLines(changes ChangeSet) ChangeSet {
+	diff, files := limitFileLines(changes.Diff, maxLinesPerFile)
+	if files > 0 {
+		logf("Truncated %d files to %d changed lines each", files, maxLinesPerFile)
+		changes.Diff = diff
+	}
+	return changes
+}
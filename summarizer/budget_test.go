@@ -0,0 +1,109 @@
+package summarizer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLimitFileLines(t *testing.T) {
+	big := "diff --git a/gen.go b/gen.go\n--- a/gen.go\n+++ b/gen.go\n@@ -1,3 +1,4 @@\n ctx\n+one\n+two\n-three\n+four\n\\ No newline at end of file\n"
+	small := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+
+	tests := []struct {
+		name      string
+		diff      string
+		maxLines  int
+		want      string
+		wantFiles int
+	}{
+		{name: "disabled", diff: big, maxLines: 0, want: big},
+		{name: "under the limit", diff: small, maxLines: 2, want: small},
+		{
+			name:      "cut after two changed lines",
+			diff:      big + small,
+			maxLines:  2,
+			want:      "diff --git a/gen.go b/gen.go\n--- a/gen.go\n+++ b/gen.go\n@@ -1,3 +1,4 @@\n ctx\n+one\n+two\n... (2 more changed lines truncated)\n" + small,
+			wantFiles: 1,
+		},
+		{
+			name:      "allowance shared between commits",
+			diff:      small + "commit abc\n" + small,
+			maxLines:  2,
+			want:      small + "commit abc\ndiff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n... (2 more changed lines truncated)\n",
+			wantFiles: 1,
+		},
+		{
+			name:      "no trailing newline",
+			diff:      "diff --git a/a b/a\n@@ -0,0 +1,2 @@\n+x\n+y",
+			maxLines:  1,
+			want:      "diff --git a/a b/a\n@@ -0,0 +1,2 @@\n+x\n... (1 more changed lines truncated)",
+			wantFiles: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, files := limitFileLines(tt.diff, tt.maxLines)
+			if got != tt.want || files != tt.wantFiles {
+				t.Errorf("limitFileLines() = %q, %d\nwant %q, %d", got, files, tt.want, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		maxChars int
+		want     string
+	}{
+		{name: "fits", diff: "+a\n+b\n", maxChars: 10, want: "+a\n+b\n"},
+		{name: "line boundary", diff: "+a\n+b\n+c\n", maxChars: 7, want: "+a\n+b\n... (diff truncated, 3 characters omitted to fit the token budget)"},
+		{name: "rune boundary within a line", diff: "héllo", maxChars: 2, want: "h... (diff truncated, 5 characters omitted to fit the token budget)"},
+		{name: "negative budget", diff: "+a\n", maxChars: -5, want: "... (diff truncated, 3 characters omitted to fit the token budget)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateDiff(tt.diff, tt.maxChars)
+			if got != tt.want {
+				t.Errorf("truncateDiff(%q, %d) = %q, want %q", tt.diff, tt.maxChars, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateDiff(%q, %d) = %q, not valid UTF-8", tt.diff, tt.maxChars, got)
+			}
+		})
+	}
+}
+
+func TestCheckTokenBudget(t *testing.T) {
+	originalMax, originalOverflow, originalSystem := maxInputTokens, onOverflow, systemPrompt
+	t.Cleanup(func() { maxInputTokens, onOverflow, systemPrompt = originalMax, originalOverflow, originalSystem })
+
+	prompt := strings.Repeat("x", 400) // about 100 tokens
+	tests := []struct {
+		name       string
+		max        int
+		onOverflow string
+		system     string
+		want       int
+		wantErr    bool
+	}{
+		{name: "disabled", max: 0, onOverflow: "abort"},
+		{name: "within budget", max: 100, onOverflow: "abort"},
+		{name: "warn", max: 50, onOverflow: "warn"},
+		{name: "truncate", max: 50, onOverflow: "truncate", want: 200},
+		{name: "abort", max: 50, onOverflow: "abort", wantErr: true},
+		{name: "system prompt over budget", max: 100, onOverflow: "abort", system: strings.Repeat("s", 40), wantErr: true},
+		{name: "truncate with system prompt", max: 50, onOverflow: "truncate", system: strings.Repeat("s", 40), want: 240},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxInputTokens, onOverflow, systemPrompt = tt.max, tt.onOverflow, tt.system
+			got, err := checkTokenBudget(prompt)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("checkTokenBudget() = %d, %v, want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
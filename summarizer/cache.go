@@ -0,0 +1,91 @@
+package summarizer
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// defaultEmbeddingCacheSize is the number of embeddings kept in memory per run.
+const defaultEmbeddingCacheSize = 128
+
+// embeddingCache is a small in-memory LRU cache of embeddings keyed by the
+// SHA-256 hash of the embedded text. It only lives for the duration of a run.
+type embeddingCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	hits     int
+	misses   int
+}
+
+type embeddingCacheEntry struct {
+	key       string
+	embedding []float64
+}
+
+// newEmbeddingCache creates an embedding cache holding at most capacity entries.
+func newEmbeddingCache(capacity int) *embeddingCache {
+	return &embeddingCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// embeddingsCache is the cache shared by all getEmbeddings calls during a run.
+var embeddingsCache = newEmbeddingCache(defaultEmbeddingCacheSize)
+
+// hashText returns the hex encoded SHA-256 hash of the given text.
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached embedding for the given text, if any, and marks it as recently used.
+func (c *embeddingCache) get(text string) ([]float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hashText(text)]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*embeddingCacheEntry).embedding, true
+}
+
+// put stores the embedding for the given text, evicting the least recently used entry when full.
+func (c *embeddingCache) put(text string, embedding []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := hashText(text)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*embeddingCacheEntry).embedding = embedding
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&embeddingCacheEntry{key: key, embedding: embedding})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*embeddingCacheEntry).key)
+	}
+}
+
+// hitRate returns the number of hits and misses and the fraction of lookups served from the cache.
+func (c *embeddingCache) hitRate() (hits, misses int, rate float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if total := c.hits + c.misses; total > 0 {
+		rate = float64(c.hits) / float64(total)
+	}
+	return c.hits, c.misses, rate
+}
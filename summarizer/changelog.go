@@ -0,0 +1,67 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// changelogPromptTemplate is the summarization prompt used with -mode changelog.
+const changelogPromptTemplate = `Here are the Git changes{{if .Embeddings}} with their semantic embeddings{{end}}:
+{{if .Embeddings}}
+Embeddings: {{.Embeddings}}
+{{end}}{{if .Compressed}}
+Compressed Changes:
+{{.Compressed}}
+{{end}}
+Original Content Summary:
+{{.Diff}}
+
+Commits:
+{{.Commits}}
+{{if .TimeRange}}
+These are all changes made {{.TimeRange}}.
+{{end}}
+Write a changelog entry for these changes in the "Keep a Changelog" style. Group the entries under
+"### Added", "### Changed", "### Fixed" and "### Removed" headings, leaving out headings without entries.
+Write one short bullet point per user-visible change. Output only the sections, without a version heading.`
+
+// defaultChangelogTemplate wraps the generated sections with a version heading.
+const defaultChangelogTemplate = `## [{{.Version}}] - {{.Date}}
+
+{{.Summary}}
+`
+
+// changelogData holds the values available to the changelog entry template.
+type changelogData struct {
+	Version string
+	Date    string
+	Summary string
+}
+
+// changelogTemplate is the changelog entry template used with -mode changelog.
+var changelogTemplate = template.Must(template.New("changelog").Parse(defaultChangelogTemplate))
+
+// changelogVersion returns the version for the changelog heading: the -version flag, the latest
+// git tag, or "Unreleased" when the repository has no tags.
+func changelogVersion(versionFlag string) string {
+	if versionFlag != "" {
+		return versionFlag
+	}
+	tag, err := getCommandOutput("git", "describe", "--tags", "--abbrev=0")
+	if err != nil || tag == "" {
+		return "Unreleased"
+	}
+	return tag
+}
+
+// renderChangelog renders the changelog entry for version with the generated summary, dated today.
+func renderChangelog(version, summary string) (string, error) {
+	var out strings.Builder
+	data := changelogData{Version: version, Date: time.Now().Format("2006-01-02"), Summary: summary}
+	if err := changelogTemplate.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("error rendering changelog entry: %v", err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,88 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// chunkThreshold is the diff size in characters above which the diff is compressed file by file
+// in batches, and the final summary is built from the per-batch summaries instead of the raw diff.
+var chunkThreshold = 100000
+
+// splitDiffByFile splits a unified git diff into one chunk per file using the "diff --git" headers.
+func splitDiffByFile(diff string) []string {
+	var files []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") && current.Len() > 0 {
+			files = append(files, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		files = append(files, current.String())
+	}
+	return files
+}
+
+// diffFilePath returns the path of the file a single-file diff chunk refers to.
+func diffFilePath(chunk string) string {
+	header, _, _ := strings.Cut(chunk, "\n")
+	if _, path, ok := strings.Cut(header, " b/"); ok {
+		return path
+	}
+	return strings.TrimPrefix(header, "diff --git ")
+}
+
+// batchDiffFiles groups consecutive file chunks into batches of at most limit characters.
+// A single file larger than limit is truncated to fit into its own batch.
+func batchDiffFiles(files []string, limit int) []string {
+	var batches []string
+	var current strings.Builder
+	for _, file := range files {
+		if len(file) > limit {
+			file = file[:runeBoundary(file, limit)] + "\n... (truncated)\n"
+		}
+		if current.Len() > 0 && current.Len()+len(file) > limit {
+			batches = append(batches, current.String())
+			current.Reset()
+		}
+		current.WriteString(file)
+	}
+	if current.Len() > 0 {
+		batches = append(batches, current.String())
+	}
+	return batches
+}
+
+// compressChunks compresses a large diff batch by batch and joins the per-batch summaries.
+// Batches that fail to compress, or compress to nothing, are represented by the list of files they
+// contain. With -fail-fast-on-ollama the first batch that fails to compress is an error instead.
+func compressChunks(ctx context.Context, detailedDiff string) (string, error) {
+	batches := batchDiffFiles(splitDiffByFile(detailedDiff), chunkThreshold)
+
+	summaries := make([]string, 0, len(batches))
+	for i, batch := range batches {
+		status.set(fmt.Sprintf("Compressing chunk %d/%d with Ollama", i+1, len(batches)))
+		summary, err := compressLogs(ctx, batch)
+		if err == nil && strings.TrimSpace(summary) == "" {
+			logf("Ollama returned an empty compression for chunk %d/%d, listing its files instead", i+1, len(batches))
+		} else if err != nil {
+			if failFastOnOllama {
+				return "", fmt.Errorf("error compressing chunk %d/%d: %w", i+1, len(batches), ollamaUnavailable(err))
+			}
+			warnf("Warning: could not compress chunk %d/%d, listing its files instead: %v", i+1, len(batches), err)
+		}
+		if err != nil || strings.TrimSpace(summary) == "" {
+			var paths []string
+			for _, file := range splitDiffByFile(batch) {
+				paths = append(paths, diffFilePath(file))
+			}
+			summary = "Changed files (not summarized): " + strings.Join(paths, ", ")
+		}
+		summaries = append(summaries, summary)
+	}
+	return strings.Join(summaries, "\n\n"), nil
+}
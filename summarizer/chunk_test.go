@@ -0,0 +1,122 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fileDiff returns a single-file diff chunk of path adding line.
+func fileDiff(path, line string) string {
+	return "diff --git a/" + path + " b/" + path + "\n--- a/" + path + "\n+++ b/" + path + "\n@@ -0,0 +1 @@\n+" + line + "\n"
+}
+
+func TestBatchDiffFiles(t *testing.T) {
+	a, b, c := fileDiff("a.go", "a"), fileDiff("b.go", "b"), fileDiff("c.go", "c")
+	tests := []struct {
+		name  string
+		files []string
+		limit int
+		want  []string
+	}{
+		{name: "all in one batch", files: []string{a, b, c}, limit: 1000, want: []string{a + b + c}},
+		{name: "split at the limit", files: []string{a, b, c}, limit: len(a) + len(b), want: []string{a + b, c}},
+		{name: "one file per batch", files: []string{a, b}, limit: len(a), want: []string{a, b}},
+		{name: "large file truncated", files: []string{a + "+more\n"}, limit: len(a), want: []string{a + "\n... (truncated)\n"}},
+		{name: "no files", files: nil, limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchDiffFiles(tt.files, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("batchDiffFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchDiffFilesKeepsUTF8(t *testing.T) {
+	file := fileDiff("i18n.txt", strings.Repeat("é", 100))
+	// An odd limit falls in the middle of a two-byte é
+	limit := strings.Index(file, "é") + 5
+	batches := batchDiffFiles([]string{file}, limit)
+	if len(batches) != 1 || !utf8.ValidString(batches[0]) {
+		t.Fatalf("batchDiffFiles() = %q, want a single valid UTF-8 batch", batches)
+	}
+	if !strings.HasSuffix(batches[0], "éé\n... (truncated)\n") {
+		t.Errorf("batchDiffFiles() = %q, want it cut after the last whole é", batches[0])
+	}
+}
+
+func TestCompressChunks(t *testing.T) {
+	a, b, c := fileDiff("a.go", "a"), fileDiff("b.go", "b"), fileDiff("c.go", "c")
+	diff := a + b + c
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		failFast bool
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:   "batches joined",
+			status: http.StatusOK,
+			body:   `{"response":"compressed","done":true}`,
+			want:   "compressed\n\ncompressed",
+		},
+		{
+			name:   "empty compression lists the files",
+			status: http.StatusOK,
+			body:   `{"response":"  ","done":true}`,
+			want:   "Changed files (not summarized): a.go, b.go\n\nChanged files (not summarized): c.go",
+		},
+		{
+			name:   "failed compression lists the files",
+			status: http.StatusInternalServerError,
+			body:   `{"error":"model crashed"}`,
+			want:   "Changed files (not summarized): a.go, b.go\n\nChanged files (not summarized): c.go",
+		},
+		{
+			name:     "failed compression with -fail-fast-on-ollama",
+			status:   http.StatusInternalServerError,
+			body:     `{"error":"model crashed"}`,
+			failFast: true,
+			wantErr:  true,
+		},
+		{
+			name:     "empty compression with -fail-fast-on-ollama lists the files",
+			status:   http.StatusOK,
+			body:     `{"response":"","done":true}`,
+			failFast: true,
+			want:     "Changed files (not summarized): a.go, b.go\n\nChanged files (not summarized): c.go",
+		},
+	}
+
+	originalThreshold, originalFailFast := chunkThreshold, failFastOnOllama
+	t.Cleanup(func() { chunkThreshold, failFastOnOllama = originalThreshold, originalFailFast })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &ollamaCompletionURL, tt.status, tt.body)
+			chunkThreshold, failFastOnOllama = len(a)+len(b), tt.failFast
+
+			got, err := compressChunks(context.Background(), diff)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compressChunks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "chunk 1/2") {
+					t.Errorf("compressChunks() error = %v, want it to name the failed chunk", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("compressChunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1422 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+var anthropicAPIKey = os.Getenv("ANTHROPIC_API_KEY")
+var openAIAPIKey = os.Getenv("OPENAI_API_KEY")
+var geminiAPIKey = os.Getenv("GEMINI_API_KEY")
+
+// openAIModel is the OpenAI chat model used with -provider openai.
+var openAIModel = "gpt-4o-mini"
+
+// geminiModel is the Gemini model used with -provider gemini.
+var geminiModel = "gemini-1.5-flash"
+
+// verbose enables diagnostic output on stderr.
+var verbose bool
+
+// quiet suppresses warnings, notices, the status line and verbose output, leaving fatal errors as the only stderr output.
+var quiet bool
+
+// logf prints a diagnostic line to stderr in verbose mode.
+func logf(format string, args ...interface{}) {
+	if verbose && !quiet {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// warnf prints a warning or notice line to stderr, clearing the status line first. Nothing is printed with -quiet.
+func warnf(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	status.clear()
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// API endpoints. These are variables so tests can point them at a local server.
+var (
+	anthropicAPIURL     = "https://api.anthropic.com/v1/messages"
+	openAIAPIURL        = "https://api.openai.com/v1/chat/completions"
+	geminiAPIURL        = "https://generativelanguage.googleapis.com/v1beta/models"
+	ollamaAPIURL        = "http://localhost:11434/api/embeddings"
+	ollamaCompletionURL = "http://localhost:11434/api/generate"
+)
+
+var anthropicModel = "claude-3-5-sonnet-latest"
+var anthropicMaxTokens = 4096
+
+// checkCancelled returns errCancelled once the run was cancelled by a signal.
+func checkCancelled(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errCancelled
+	}
+	return nil
+}
+
+// Main runs the prgpt command line with args, the arguments after the program name, and returns
+// the exit code. The prgpt command is a thin wrapper around it.
+func Main(ctx context.Context, args []string) int {
+	err := run(ctx, args)
+	status.clear()
+	if showMetrics {
+		metrics.report(os.Stderr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode(err)
+	}
+	return 0
+}
+
+// run runs the subcommand selected by args. Running prgpt without a subcommand runs pr.
+func run(ctx context.Context, args []string) error {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			fmt.Print(usage())
+			return nil
+		}
+		if cmd, ok := findCommand(args[0]); ok {
+			return cmd.run(ctx, args[1:])
+		}
+		// Anything else is the base branch passed as positional argument to pr
+	}
+	// The flags may come before a subcommand, which pr finds once it parsed them
+	return runPRArgs(ctx, args, true)
+}
+
+// cmdFlags is the flag set of the running subcommand.
+var cmdFlags = flag.CommandLine
+
+// options holds the flags shared by the pr and diff subcommands.
+type options struct {
+	providerName       string
+	excludes           stringListFlag
+	promptTemplatePath string
+	prTemplatePath     string
+	anthropicOnly      bool
+	stream             bool
+	dryRun             bool
+	statsOnly          bool // pr -stats-only: render the template without a summary
+	format             string
+	outputPath         string
+	copySummary        bool
+	force              bool
+	render             bool // -render, only left set when stdout is a terminal that may be styled
+	postProcess        string
+	logFile            string
+}
+
+// newFlagSet creates the flag set of a subcommand with the settings flags every subcommand accepts.
+// These are the flags the config file and -print-config know about.
+func newFlagSet(name, synopsis string) (*flag.FlagSet, *options, error) {
+	fs := flag.NewFlagSet("prgpt "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+	cmdFlags = fs
+
+	o := &options{}
+	fs.StringVar(&o.providerName, "provider", "anthropic", "summary provider: anthropic, openai, azure, gemini, bedrock, ollama for a local model, or mock for an offline summary of the commits and files")
+	fs.BoolVar(&localMode, "local", false, "run the whole pipeline on the Ollama server, generating the summary with -ollama-model too, so no diff is sent to a cloud API (same as -provider ollama)")
+	fs.StringVar(&ollamaSummaryModel, "ollama-model", envOr("PRGPT_OLLAMA_MODEL", ollamaSummaryModel), "Ollama model used for the summary with -local or -provider ollama (defaults to -compress-model, env PRGPT_OLLAMA_MODEL)")
+	fs.StringVar(&anthropicModel, "model", envOr("PRGPT_MODEL", anthropicModel), "Anthropic model used for the summary (env PRGPT_MODEL)")
+	defaultMaxTokens, err := envInt("PRGPT_MAX_TOKENS", anthropicMaxTokens)
+	if err != nil {
+		return nil, nil, fail(exitConfig, "Error: %v", err)
+	}
+	fs.IntVar(&anthropicMaxTokens, "max-tokens", defaultMaxTokens, "maximum number of tokens in the Anthropic, Bedrock or Ollama response (env PRGPT_MAX_TOKENS)")
+	fs.StringVar(&openAIModel, "openai-model", openAIModel, "OpenAI model used with -provider openai")
+	fs.StringVar(&azureDeployment, "deployment", envOr("AZURE_OPENAI_DEPLOYMENT", azureDeployment), "Azure OpenAI deployment used with -provider azure (env AZURE_OPENAI_DEPLOYMENT)")
+	fs.StringVar(&azureAPIVersion, "api-version", azureAPIVersion, "Azure OpenAI API version used with -provider azure")
+	fs.StringVar(&geminiModel, "gemini-model", geminiModel, "Gemini model used with -provider gemini")
+	fs.StringVar(&bedrockModel, "bedrock-model", bedrockModel, "Bedrock model ID of the Claude model used with -provider bedrock")
+	fs.StringVar(&bedrockRegion, "region", bedrockRegion, "AWS region used with -provider bedrock (env AWS_REGION)")
+	defaultTimeout, err := envTimeout()
+	if err != nil {
+		return nil, nil, fail(exitConfig, "Error: %v", err)
+	}
+	fs.IntVar(&maxRetries, "max-retries", maxRetries, "number of times to retry transient API failures")
+	fs.DurationVar(&maxRetryWait, "max-retry-wait", maxRetryWait, "longest wait before a retry that a Retry-After header of a rate limited response can ask for")
+	fs.DurationVar(&httpClient.Timeout, "timeout", defaultTimeout, "timeout for each API request (env PRGPT_TIMEOUT)")
+	fs.StringVar(&ollamaURL, "ollama-url", envOr("OLLAMA_HOST", ollamaURL), "base URL of the Ollama server (env OLLAMA_HOST)")
+	fs.StringVar(&ollamaEmbeddingModel, "embed-model", envOr("PRGPT_EMBED_MODEL", ollamaEmbeddingModel), "Ollama model used for embeddings (env PRGPT_EMBED_MODEL)")
+	fs.StringVar(&ollamaCompletionModel, "compress-model", envOr("PRGPT_COMPRESS_MODEL", ollamaCompletionModel), "Ollama model used to compress the diff (env PRGPT_COMPRESS_MODEL)")
+	fs.Var(&baseCandidates, "base-candidate", "ref tried as the base branch when -base isn't given, in order (repeatable, default origin/HEAD, main, master, develop)")
+	fs.Var(&issuePatterns, "issue-pattern", "regular expression matching issue references for -link-issues (repeatable, default '#\\d+' and '[A-Z]+-\\d+')")
+	fs.Var(&o.excludes, "exclude", "glob of paths to leave out of the diff, e.g. '*.lock' or 'vendor/**' (repeatable)")
+	fs.StringVar(&o.promptTemplatePath, "prompt-template", "", "file with a text/template summarization prompt (overrides .prgpt/prompt.md)")
+	fs.StringVar(&systemPrompt, "system-prompt", defaultSystemPrompt, "system prompt sent to the summary provider separately from the changes (empty to send none)")
+	fs.StringVar(&prPlatform, "platform", prPlatform, "platform the PR markdown is written for: github, gitlab, bitbucket or gitea (selects the template and issue closing syntax)")
+	fs.StringVar(&o.prTemplatePath, "pr-template", "", "file with a text/template for the PR markdown")
+	fs.Var(&temperature, "temperature", "sampling temperature of the summary model, lower for more consistent summaries: 0-1 for anthropic and bedrock, 0-2 for openai, azure and gemini (defaults to the provider's)")
+	fs.Var(&topP, "top-p", "nucleus sampling top_p of the summary model, between 0 and 1 (defaults to the provider's)")
+	fs.BoolVar(&skipEmbeddings, "no-embeddings", false, "skip the Ollama embeddings step and leave embeddings out of the prompt")
+	fs.Var(&contextFiles, "context-file", fmt.Sprintf("file such as README.md or CHANGELOG.md sent as project background in the system prompt, up to %d characters each (repeatable)", maxContextFileChars))
+	fs.BoolVar(&skipStackHint, "no-stack-hint", false, "don't tell the summary provider the languages of the changed files and the build tools of the repository")
+	fs.Var(&stackExtensions, "stack-ext", "map a file extension to a language for the stack hint, e.g. .vue=Vue (repeatable)")
+	fs.BoolVar(&skipFileGroups, "no-file-groups", false, "don't embed each changed file to group related files in the prompt")
+	fs.BoolVar(&skipCompression, "no-compress", false, "send the raw diff to the summary provider without Ollama compression (uses more input tokens)")
+	fs.BoolVar(&o.anthropicOnly, "anthropic-only", false, "skip all Ollama calls, same as -no-compress -no-embeddings")
+	fs.BoolVar(&failFastOnOllama, "fail-fast-on-ollama", false, "abort when the Ollama compression or embeddings fail instead of skipping the step with a warning")
+	fs.StringVar(&embedSource, "embed-source", embedSource, "what to embed: compressed (after compression) or raw (the diff itself, concurrently with compression)")
+	fs.StringVar(&embedEncoding, "embed-encoding", embedEncoding, "how the embeddings are packed in the prompt before base64: json (a JSON array) or float32 (little-endian bytes, about a quarter of the size)")
+	fs.BoolVar(&redactSecrets, "redact-secrets", redactSecrets, "replace likely secrets (keys, tokens, private keys) in the diff with ***REDACTED*** before it is sent anywhere")
+	fs.BoolVar(&noDiskCache, "no-cache", false, "don't read or write the on-disk compression cache")
+	fs.IntVar(&chunkThreshold, "chunk-threshold", chunkThreshold, "diff size in characters above which the diff is compressed in chunks")
+	fs.IntVar(&maxLinesPerFile, "max-lines-per-file", 0, "keep only the first N changed lines of each file in the diff, noting how many were cut (0 keeps all)")
+	fs.IntVar(&maxInputTokens, "max-input-tokens", maxInputTokens, "estimated prompt size in tokens above which -on-overflow applies (0 disables)")
+	fs.StringVar(&onOverflow, "on-overflow", onOverflow, "what to do with a prompt over -max-input-tokens: warn, truncate or abort")
+	fs.BoolVar(&promptCaching, "prompt-cache", false, "mark the prompt for Anthropic prompt caching; text before {{cacheBreakpoint}} in the template is cached separately")
+	fs.BoolVar(&verbose, "verbose", false, "print diagnostic output to stderr")
+	fs.BoolVar(&verbose, "v", false, "shorthand for -verbose")
+	fs.BoolVar(&quiet, "quiet", false, "print nothing but the result on stdout and fatal errors on stderr (overrides -verbose)")
+	fs.BoolVar(&quiet, "q", false, "shorthand for -quiet")
+	fs.BoolVar(&showMetrics, "metrics", false, "print the time spent in git, Ollama and the summary provider and the tokens used to stderr at the end of the run")
+	fs.Var(&customHeaders, "header", "add a \"Name: Value\" header to every summary provider request, e.g. the token of an LLM gateway (repeatable)")
+	fs.StringVar(&apiBase, "api-base", "", "base URL of the summary provider API, e.g. an internal gateway speaking the Anthropic or OpenAI protocol")
+	fs.StringVar(&apiKeyFlag, "api-key", "", "API key of the -provider, or a file:path or cmd:command reference to read it from, e.g. \"cmd:op read op://vault/item/key\" (takes precedence over -api-key-file and the environment)")
+	fs.StringVar(&apiKeyFileFlag, "api-key-file", "", "file holding the API key of the -provider, also set with <KEY VARIABLE>_FILE such as ANTHROPIC_API_KEY_FILE (takes precedence over the key variable)")
+	fs.StringVar(&o.logFile, "log-file", "", "append a JSON line with the request and response of every API call to this file (headers and API keys are never logged)")
+	fs.BoolVar(&strictJSON, "strict-json", false, "reject API responses that don't match the expected shape")
+	return fs, o, nil
+}
+
+// addOutputFlags adds the flags controlling how a summary is generated and written out.
+func addOutputFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.stream, "stream", false, "print the summary as it is generated (anthropic only)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print the prompt that would be sent to the summary provider and exit")
+	fs.StringVar(&o.format, "format", "markdown", "output format: markdown or json")
+	fs.StringVar(&o.outputPath, "output", "", "write the summary to this file instead of stdout")
+	fs.BoolVar(&o.copySummary, "copy", false, "also copy the summary to the system clipboard")
+	fs.BoolVar(&o.force, "force", false, "overwrite the -output file if it already exists")
+	fs.StringVar(&o.postProcess, "post-process", "", "pipe the result through this shell command and emit its stdout instead, e.g. to prepend a JIRA smart-commit line; when it fails the result is emitted unchanged and the run ends with an error (-stream then streams to stderr)")
+	fs.BoolVar(&o.render, "render", false, "style the markdown summary with ANSI colors when stdout is a terminal and NO_COLOR is unset (-stream then streams to stderr)")
+}
+
+// loadSettings finishes the configuration once the flags are parsed: it applies -anthropic-only
+// and the config file. It returns the repository root, which is empty outside a repository.
+func loadSettings(o *options) (string, error) {
+	if o.anthropicOnly || o.statsOnly {
+		skipCompression, skipEmbeddings = true, true
+	}
+
+	// Passing -no-embeddings=false explicitly disables the automatic fallback when Ollama is unreachable
+	var providerSet bool
+	cmdFlags.Visit(func(f *flag.Flag) {
+		if f.Name == "no-embeddings" && !skipEmbeddings {
+			requireEmbeddings = true
+		}
+		providerSet = providerSet || f.Name == "provider"
+	})
+
+	// The repository root is optional at this point so some commands also work outside a repository
+	repoRoot, err := getCommandOutput("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		logf("Not in a git repository: %v", err)
+		repoRoot = ""
+	}
+
+	configPaths, err := loadConfigFile(repoRoot)
+	if err != nil {
+		return "", fail(exitConfig, "Error loading config file: %v", err)
+	}
+	for _, path := range configPaths {
+		logf("Using config file %s", path)
+	}
+	if err := applyLocalMode(o, providerSet); err != nil {
+		return "", fail(exitConfig, "Error: %v", err)
+	}
+	// The mock provider works without any API, Ollama included
+	if o.providerName == "mock" {
+		skipCompression, skipEmbeddings = true, true
+	}
+	setOllamaURL(ollamaURL)
+	if (apiKeyFlag != "" || apiKeyFileFlag != "") && apiKeyVar(o.providerName) == nil {
+		return "", fail(exitConfig, "Error: -api-key and -api-key-file are not used by -provider %s", o.providerName)
+	}
+	apiKeyProvider = o.providerName
+	if apiBase != "" {
+		if err := setAPIBase(o.providerName, apiBase); err != nil {
+			return "", fail(exitConfig, "Error: -api-base: %v", err)
+		}
+	}
+	logCustomHeaders()
+	return repoRoot, nil
+}
+
+// validateSettings checks the settings shared by the subcommands that generate a summary.
+func validateSettings(o *options) error {
+	if httpClient.Timeout <= 0 {
+		return fail(exitConfig, "Error: -timeout must be positive")
+	}
+
+	if maxLinesPerFile < 0 {
+		return fail(exitConfig, "Error: -max-lines-per-file must not be negative")
+	}
+
+	if maxRetries < 0 {
+		return fail(exitConfig, "Error: -max-retries must not be negative")
+	}
+
+	if maxRetryWait < 0 {
+		return fail(exitConfig, "Error: -max-retry-wait must not be negative")
+	}
+
+	if ollamaEmbeddingModel == "" || ollamaCompletionModel == "" {
+		return fail(exitConfig, "Error: -embed-model and -compress-model must not be empty")
+	}
+
+	if o.format != "markdown" && o.format != "json" {
+		return fail(exitConfig, "Error: -format must be markdown or json")
+	}
+	// Redirected output, files and JSON always get the raw text
+	o.render = o.render && o.format == "markdown" && o.outputPath == "" && colorEnabled()
+
+	if onOverflow != "warn" && onOverflow != "truncate" && onOverflow != "abort" {
+		return fail(exitConfig, "Error: -on-overflow must be warn, truncate or abort")
+	}
+
+	if o.providerName == "anthropic" {
+		if err := validateMaxTokens(anthropicModel, anthropicMaxTokens); err != nil {
+			return fail(exitConfig, "Error: %v", err)
+		}
+	}
+
+	if o.providerName == "bedrock" {
+		if err := validateMaxTokens(bedrockClaudeModel(bedrockModel), anthropicMaxTokens); err != nil {
+			return fail(exitConfig, "Error: %v", err)
+		}
+	}
+
+	if err := validateSampling(o.providerName); err != nil {
+		return fail(exitConfig, "Error: %v", err)
+	}
+
+	if err := loadContextFiles(); err != nil {
+		return fail(exitConfig, "Error: -context-file: %v", err)
+	}
+
+	if _, err := extensionLanguages(); err != nil {
+		return fail(exitConfig, "Error: -stack-ext: %v", err)
+	}
+
+	if err := validatePlatform(prPlatform); err != nil {
+		return fail(exitConfig, "Error: -platform: %v", err)
+	}
+
+	if embedSource != "compressed" && embedSource != "raw" {
+		return fail(exitConfig, "Error: -embed-source must be compressed or raw")
+	}
+
+	if embedEncoding != "json" && embedEncoding != "float32" {
+		return fail(exitConfig, "Error: -embed-encoding must be json or float32")
+	}
+
+	if chunkThreshold <= 0 {
+		return fail(exitConfig, "Error: -chunk-threshold must be positive")
+	}
+
+	if o.logFile != "" {
+		logger, err := openAPILog(o.logFile)
+		if err != nil {
+			return fail(exitConfig, "Error: %v", err)
+		}
+		apiLog = logger
+	}
+
+	// Refuse to clobber an existing file before doing any expensive work
+	if o.outputPath != "" && !o.force {
+		if _, err := os.Stat(o.outputPath); err == nil {
+			return fail(exitConfig, "Error: %s already exists, use -force to overwrite", o.outputPath)
+		}
+	}
+	return nil
+}
+
+// setupProvider creates the summary provider and loads the prompt and PR templates.
+// When Ollama doesn't answer the health check, compression and embeddings are turned off.
+func setupProvider(ctx context.Context, o *options, repoRoot string) (SummaryProvider, error) {
+	provider, err := newProvider(o.providerName)
+	if err != nil {
+		return nil, fail(exitConfig, "Error: %v", err)
+	}
+
+	// Fail before the git and Ollama work when the summary call can't succeed anyway
+	if !o.dryRun && !o.statsOnly {
+		if err := checkCredentials(o.providerName); err != nil {
+			return nil, fail(exitConfig, "Error: %v", err)
+		}
+	}
+
+	logf("Provider: %s (model %s)", o.providerName, providerModel(o.providerName))
+	logf("Ollama models: %s for embeddings, %s for compression", ollamaEmbeddingModel, ollamaCompletionModel)
+	if err := prepareRun(ctx, o, repoRoot); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// prepareRun loads the prompt and PR templates and checks that Ollama is reachable for the steps
+// that need it, turning compression and embeddings off when it isn't.
+func prepareRun(ctx context.Context, o *options, repoRoot string) error {
+	tmpl, err := loadPromptTemplate(repoRoot, o.promptTemplatePath)
+	if err != nil {
+		return fail(exitConfig, "Error loading prompt template: %v", err)
+	}
+	promptTemplate = tmpl
+
+	// Load the PR template up front so a typo fails before any API call
+	prTmpl, err := loadPRTemplate(repoRoot, o.prTemplatePath)
+	if err != nil {
+		return fail(exitConfig, "Error loading PR template: %v", err)
+	}
+	prTemplate = prTmpl
+
+	if o.providerName == "ollama" && !o.dryRun && !o.statsOnly {
+		// Without Ollama there is no summary either
+		if err := checkOllama(ctx); err != nil {
+			return fail(exitAPI, "Error: %w", err)
+		}
+	} else if !skipCompression || !skipEmbeddings {
+		if err := checkOllama(ctx); err != nil {
+			if requireEmbeddings || failFastOnOllama {
+				return fail(exitAPI, "Error: %w", err)
+			}
+			warnf("Warning: Ollama at %s is unreachable, continuing without compression and embeddings", ollamaURL)
+			skipCompression, skipEmbeddings = true, true
+		}
+	}
+	return nil
+}
+
+// setupCompareProviders creates the summary providers of the -compare targets and checks their credentials.
+func setupCompareProviders(o *options, targets []compareTarget) ([]SummaryProvider, error) {
+	providers := make([]SummaryProvider, len(targets))
+	for i, target := range targets {
+		provider, err := newTargetProvider(target)
+		if err != nil {
+			return nil, fail(exitConfig, "Error: -compare: %v", err)
+		}
+		if !o.dryRun {
+			if err := checkCredentials(target.provider); err != nil {
+				return nil, fail(exitConfig, "Error: -compare %s: %v", target.provider, err)
+			}
+		}
+		providers[i] = provider
+	}
+	return providers, nil
+}
+
+// deliver writes the result to the -output file, or to stdout unless it was already streamed there,
+// and copies it to the clipboard with -copy.
+func deliver(o *options, result string, printed bool) error {
+	// The status line shares the terminal with stdout
+	status.clear()
+	switch {
+	case o.outputPath != "":
+		if err := writeOutput(o.outputPath, result, o.force); err != nil {
+			return fail(exitFailure, "Error writing output: %v", err)
+		}
+		warnf("Summary written to %s", o.outputPath)
+	case !printed && o.render:
+		fmt.Println(renderMarkdown(result))
+	case !printed:
+		fmt.Println(result)
+	}
+
+	if o.copySummary {
+		if err := copyToClipboard(result); err != nil {
+			warnf("Warning: could not copy to clipboard: %v", err)
+		} else {
+			warnf("Summary copied to clipboard")
+		}
+	}
+
+	hits, misses, rate := embeddingsCache.hitRate()
+	logf("Embedding cache: %d hits, %d misses (%.0f%% hit rate)", hits, misses, rate*100)
+	return nil
+}
+
+// runPR implements the pr subcommand, summarizing the commits of a branch for a pull request.
+func runPR(ctx context.Context, args []string) error {
+	return runPRArgs(ctx, args, false)
+}
+
+// runPRArgs runs pr with args. With subcommands, a subcommand following the flags runs instead.
+func runPRArgs(ctx context.Context, args []string, subcommands bool) error {
+	fs, o, err := newFlagSet("pr", "prgpt [pr] [flags] [base]")
+	if err != nil {
+		return err
+	}
+	addOutputFlags(fs, o)
+	baseFlag := fs.String("base", "", "base ref to compare against (defaults to the first existing -base-candidate)")
+	fetch := fs.Bool("fetch", false, "fetch the base branch from its remote before comparing, falling back to the local ref when the fetch fails; without it prgpt only warns when the local base is behind its remote-tracking branch")
+	headFlag := fs.String("head", "", "head ref to summarize (defaults to the current branch)")
+	since := fs.String("since", "", "summarize the commits on the head ref since a git date, e.g. '2 weeks ago' or 2024-01-01, instead of a base branch")
+	staged := fs.Bool("staged", false, "summarize staged changes (git diff --cached) instead of a commit range")
+	working := fs.Bool("working", false, "summarize all uncommitted changes in the working tree instead of a commit range")
+	var paths stringListFlag
+	vars := keyValueFlag{}
+	fs.Var(&paths, "path", "only summarize changes under this path, relative to the repository root, e.g. services/api (repeatable)")
+	diffAlgorithm := fs.String("diff-algorithm", "", "git diff algorithm: myers, minimal, patience or histogram (defaults to git's myers)")
+	wordDiff := fs.Bool("word-diff", false, "diff changed words instead of whole lines, which suits prose-heavy repositories")
+	findRenames := fs.Int("find-renames", defaultRenameThreshold, "similarity in percent at which a deleted and an added file count as a rename, listed as moved in the overview (0 disables rename detection)")
+	findCopies := fs.Bool("find-copies", false, "also detect files copied from a file changed in the same diff, at the -find-renames similarity")
+	contextLines := fs.Int("context", defaultContextLines, "lines of context around each change in the diff: more for subtle logic changes, fewer for large mechanical ones")
+	noMerges := fs.Bool("no-merges", false, "leave merge commits out of the commit list and summarize only the changes of the other commits, which drops changes merged in from other branches")
+	linkIssues := fs.Bool("link-issues", false, "list the issues referenced in the branch name and commit subjects (see -issue-pattern) and ask the model to mention them")
+	fs.Var(vars, "var", "make a key=value pair available to the PR template as {{.Vars.key}}, e.g. jira=PROJ (repeatable)")
+	perCommit := fs.Bool("per-commit", false, fmt.Sprintf("also summarize each listed commit in one line, shown as \"hash — summary\" in the commit list; makes one API call per commit, %d at a time (see -max-commits)", perCommitConcurrency))
+	maxCommits := fs.Int("max-commits", 0, "list only this many of the most recent commits and collapse the rest into a \"(+N earlier commits)\" line (0 lists all)")
+	allowEmpty := fs.Bool("allow-empty", false, "print the PR template even when there are no commits or changes")
+	mode := fs.String("mode", "pr", "what to generate: pr for a PR description, changelog for a Keep a Changelog entry")
+	version := fs.String("version", "", "version heading for -mode changelog (defaults to the latest git tag)")
+	fs.BoolVar(&o.statsOnly, "stats-only", false, "render the template with the commits and changes overview but an empty summary, without calling Ollama or a summary provider")
+	titleOnly := fs.Bool("title-only", false, "generate only a one-line conventional-commit style PR title and print it without the template")
+	structured := fs.Bool("structured", false, "generate the summary as JSON with title, summary, breaking_changes and test_plan fields, schema-enforced with -provider anthropic, openai and azure; the fields are rendered into the template ({{.Title}} holds the title) or added to -format json as \"structured\"")
+	createPR := fs.Bool("create-pr", false, "open a GitHub pull request with the summary as its body using the gh CLI")
+	prTitle := fs.String("title", "", "title for -create-pr (defaults to the -structured title or the most recent commit subject)")
+	interactive := fs.Bool("interactive", false, "after the summary, read instructions such as \"make it shorter\" from stdin to revise it (:save writes it to -output, :quit exits); needs -provider anthropic")
+	compare := fs.String("compare", "", "compare the summaries of several providers or models, e.g. anthropic,openai:gpt-4o,gemini, printed under a heading each instead of the template")
+	injectInto := fs.String("inject-into", "", "write the summary between the <!-- prgpt:summary --> and <!-- /prgpt:summary --> markers of this file, e.g. .github/PULL_REQUEST_TEMPLATE.md, instead of printing it")
+	clearCache := fs.Bool("clear-cache", false, "delete the on-disk compression cache and exit")
+	showConfig := fs.Bool("print-config", false, "print the resolved configuration and exit (same as prgpt config)")
+	configFormat := fs.String("print-config-format", "table", "format for -print-config: table or json")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage())
+		fmt.Fprintf(fs.Output(), "\nUsage of pr: prgpt [pr] [flags] [base]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if cmd, rest, ok := commandAfterFlags(fs, args); subcommands && ok {
+		return cmd.run(ctx, rest)
+	}
+
+	// -api-key applies to a single provider, while -compare reads the key of every target from the environment
+	if *compare != "" && (apiKeyFlag != "" || apiKeyFileFlag != "") {
+		return fail(exitConfig, "Error: -api-key and -api-key-file cannot be combined with -compare, set the key variable of each provider instead")
+	}
+
+	repoRoot, err := loadSettings(o)
+	if err != nil {
+		return err
+	}
+
+	if *clearCache {
+		if err := clearDiskCache(); err != nil {
+			return fail(exitFailure, "Error clearing cache: %v", err)
+		}
+		warnf("Cache cleared")
+		return nil
+	}
+
+	if *showConfig {
+		if err := printConfig(os.Stdout, *configFormat); err != nil {
+			return fail(exitFailure, "Error printing config: %v", err)
+		}
+		return nil
+	}
+
+	if err := validateSettings(o); err != nil {
+		return err
+	}
+
+	extraDiffArgs, err := diffOptions(*diffAlgorithm, *wordDiff, *contextLines)
+	if err != nil {
+		return fail(exitConfig, "Error: %v", err)
+	}
+	// The stat overview and file list pair renamed files like the diff does
+	renameArgs, err := renameOptions(*findRenames, *findCopies)
+	if err != nil {
+		return fail(exitConfig, "Error: %v", err)
+	}
+	extraDiffArgs = append(extraDiffArgs, renameArgs...)
+
+	var issueRegexps []*regexp.Regexp
+	if *linkIssues {
+		if issueRegexps, err = compileIssuePatterns(); err != nil {
+			return fail(exitConfig, "Error: -issue-pattern: %v", err)
+		}
+	}
+
+	if *maxCommits < 0 {
+		return fail(exitConfig, "Error: -max-commits must not be negative")
+	}
+
+	if *mode != "pr" && *mode != "changelog" {
+		return fail(exitConfig, "Error: -mode must be pr or changelog")
+	}
+
+	if *titleOnly && (*mode != "pr" || *createPR || o.format != "markdown") {
+		return fail(exitConfig, "Error: -title-only cannot be combined with -mode changelog, -create-pr or -format json")
+	}
+
+	if *injectInto != "" && (*titleOnly || o.outputPath != "" || o.format != "markdown") {
+		return fail(exitConfig, "Error: -inject-into cannot be combined with -title-only, -output or -format json")
+	}
+
+	if *injectInto != "" {
+		if _, err := os.Stat(*injectInto); err != nil {
+			return fail(exitConfig, "Error: -inject-into: %v", err)
+		}
+	}
+
+	if o.statsOnly && (*titleOnly || *interactive || *compare != "" || *injectInto != "" || o.dryRun) {
+		return fail(exitConfig, "Error: -stats-only cannot be combined with -title-only, -interactive, -compare, -inject-into or -dry-run")
+	}
+	if o.statsOnly {
+		// There is no summary to stream
+		o.stream = false
+	}
+
+	if *perCommit && (*titleOnly || o.statsOnly || *compare != "" || *mode != "pr") {
+		return fail(exitConfig, "Error: -per-commit cannot be combined with -title-only, -stats-only, -compare or -mode changelog")
+	}
+
+	if *structured && (*titleOnly || o.statsOnly || *compare != "" || *interactive || *mode != "pr") {
+		return fail(exitConfig, "Error: -structured cannot be combined with -title-only, -stats-only, -compare, -interactive or -mode changelog")
+	}
+	if *structured {
+		// The JSON reply is only usable once it is complete
+		o.stream = false
+	}
+
+	if *interactive && (*titleOnly || *createPR || *injectInto != "" || *compare != "" || o.format != "markdown" || o.postProcess != "" || quiet) {
+		return fail(exitConfig, "Error: -interactive cannot be combined with -title-only, -create-pr, -inject-into, -compare, -format json, -post-process or -quiet")
+	}
+
+	var compareTargets []compareTarget
+	if *compare != "" {
+		if *titleOnly || *createPR || *injectInto != "" || o.format != "markdown" {
+			return fail(exitConfig, "Error: -compare cannot be combined with -title-only, -create-pr, -inject-into or -format json")
+		}
+		if compareTargets, err = parseCompareTargets(*compare); err != nil {
+			return fail(exitConfig, "Error: -compare: %v", err)
+		}
+		for _, target := range compareTargets {
+			if localMode && target.provider != "ollama" {
+				return fail(exitConfig, "Error: -local can only compare ollama models, e.g. ollama:llama3.2,ollama:qwen2.5-coder")
+			}
+		}
+		// The targets replace -provider, which only checks the first one's credentials in setupProvider
+		o.providerName = compareTargets[0].provider
+	}
+
+	if *createPR && *mode != "pr" {
+		return fail(exitConfig, "Error: -create-pr requires -mode pr")
+	}
+
+	if *createPR && (*staged || *working || *since != "") {
+		return fail(exitConfig, "Error: -create-pr needs a base branch and cannot be combined with -staged, -working or -since")
+	}
+
+	if *createPR && o.format != "markdown" {
+		return fail(exitConfig, "Error: -create-pr requires -format markdown")
+	}
+
+	if *createPR && !o.dryRun {
+		if err := checkGHReady(); err != nil {
+			return fail(exitConfig, "Error: -create-pr: %v", err)
+		}
+	}
+
+	if !insideWorkTree() || repoRoot == "" {
+		return fail(exitFailure, "prgpt must be run inside a git repository")
+	}
+
+	provider, err := setupProvider(ctx, o, repoRoot)
+	if err != nil {
+		return err
+	}
+	compareProviders, err := setupCompareProviders(o, compareTargets)
+	if err != nil {
+		return err
+	}
+	conversation, ok := provider.(ConversationProvider)
+	if *interactive && !ok {
+		return fail(exitConfig, "Error: -interactive is not supported by -provider %s, use anthropic", o.providerName)
+	}
+	switch {
+	case *titleOnly:
+		promptTemplate = template.Must(newPromptTemplate("title-prompt", titlePromptTemplate))
+		useTemplateInstructions()
+	case *mode == "changelog" && o.promptTemplatePath == "":
+		promptTemplate = template.Must(newPromptTemplate("changelog-prompt", changelogPromptTemplate))
+		useTemplateInstructions()
+	}
+
+	switch {
+	case *staged && *working:
+		return fail(exitConfig, "Error: -staged and -working cannot be combined")
+	case *since != "" && (*staged || *working || *baseFlag != ""):
+		return fail(exitConfig, "Error: -since cannot be combined with -base, -staged or -working")
+	case *noMerges && (*staged || *working):
+		return fail(exitConfig, "Error: -no-merges needs a commit range and cannot be combined with -staged or -working")
+	case *perCommit && (*staged || *working):
+		return fail(exitConfig, "Error: -per-commit needs a commit range and cannot be combined with -staged or -working")
+	case *fetch && (*staged || *working || *since != ""):
+		return fail(exitConfig, "Error: -fetch needs a base branch and cannot be combined with -staged, -working or -since")
+	}
+
+	gathered, err := gatherChanges(ctx, repoRoot, changeRequest{
+		base:       *baseFlag,
+		head:       *headFlag,
+		since:      *since,
+		staged:     *staged,
+		working:    *working,
+		paths:      paths,
+		excludes:   o.excludes,
+		noMerges:   *noMerges,
+		fetch:      *fetch,
+		allowEmpty: *allowEmpty,
+		diffArgs:   extraDiffArgs,
+		renameArgs: renameArgs,
+	})
+	if err != nil {
+		return err
+	}
+	changes, baseBranch, currentBranch, merges, pathArgs := gathered.changes, gathered.base, gathered.head, gathered.merges, gathered.pathArgs
+	commits, detailedDiff, changesOverview, fileStats := changes.Commits, changes.Diff, changes.Overview, changes.Files
+
+	// A -structured title replaces the default title, but not one given with -title
+	titleGiven := *prTitle != ""
+	if *createPR && *prTitle == "" {
+		*prTitle, err = defaultPRTitle(baseBranch, currentBranch)
+		if err != nil {
+			return fail(exitFailure, "Error deriving PR title: %v", err)
+		}
+	}
+
+	if detailedDiff == "" {
+		// Commits without file changes (e.g. merges) leave nothing to summarize
+		warnf("Warning: no file changes to summarize")
+	}
+
+	// With -max-commits the template and prompt list the latest commits, and the prompt gets the themes of the rest
+	listedCommits, promptCommits := commits, commits
+	if baseBranch != "" || *since != "" {
+		var earlier []commit
+		listedCommits, earlier = capCommitList(commits, *maxCommits)
+		promptCommits = listedCommits
+		if len(earlier) > 0 {
+			promptCommits += "\nThemes of the earlier commits: " + commitThemes(earlier)
+		}
+	}
+
+	var issues []string
+	if *linkIssues {
+		issues = extractIssueRefs(append([]string{currentBranch}, strings.Split(commits, "\n")...), issueRegexps)
+		logf("Found %d issue references", len(issues))
+	}
+
+	addStackHint(repoRoot, fileStats)
+	if projectContext != "" {
+		appendSystemPrompt(projectContext)
+	}
+	changes.Commits, changes.Issues = promptCommits, issues
+
+	if o.dryRun {
+		return printPrompt(ctx, changes)
+	}
+
+	if len(compareTargets) > 0 {
+		if detailedDiff == "" {
+			return fail(exitFailure, "Error: %v", ErrEmptyDiff)
+		}
+		results, err := compareSummaries(ctx, compareTargets, compareProviders, changes)
+		if err != nil {
+			return err
+		}
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		result, postErr := applyPostProcess(o, renderComparison(results))
+		if err := deliver(o, result, false); err != nil {
+			return err
+		}
+		if postErr != nil {
+			return postErr
+		}
+		return comparisonError(results)
+	}
+
+	// The per-commit summaries cover the listed commits, so -max-commits bounds the API calls
+	var commitNotes map[string]string
+	if *perCommit {
+		listed := parseCommits(commits, true)
+		if *maxCommits > 0 && len(listed) > *maxCommits {
+			listed = listed[:*maxCommits]
+		}
+		commitNotes = summarizeCommits(ctx, provider, listed, extraDiffArgs, pathArgs)
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		listedCommits = annotateCommits(listedCommits, commitNotes)
+	}
+
+	if *titleOnly {
+		summary, err := getSummary(ctx, provider, changes, nil)
+		if errors.Is(err, ErrEmptyDiff) {
+			return fail(exitFailure, "Error: no file changes to generate a title from")
+		}
+		if err != nil {
+			return err
+		}
+		title, postErr := applyPostProcess(o, cleanTitle(summary))
+		if err := deliver(o, title, false); err != nil {
+			return err
+		}
+		return postErr
+	}
+
+	// render lays out the markdown around the summary for the selected mode
+	var structuredSummary *StructuredSummary
+	render := func(summary string) (string, error) {
+		var title string
+		if structuredSummary != nil {
+			title = structuredSummary.Title
+		}
+		return renderPRSummary(PRData{Branch: currentBranch, Since: *since, Commits: listedCommits, Merges: merges, Issues: issueList(issues), Overview: changesOverview, Title: title, Summary: summary, Vars: vars})
+	}
+	if *mode == "changelog" {
+		entryVersion := changelogVersion(*version)
+		render = func(summary string) (string, error) {
+			return renderChangelog(entryVersion, summary)
+		}
+	}
+
+	// A failed summary still prints the template, but the run ends with the summary error
+	var summary, prSummary string
+	var summaryErr error
+	var printed bool
+	if o.stream && o.outputPath == "" && *injectInto == "" && o.format == "markdown" && !o.render && o.postProcess == "" {
+		// Print the template around the summary while it streams in
+		skeleton, err := render(summaryPlaceholder)
+		if err != nil {
+			return fail(exitFailure, "Error: %v", err)
+		}
+		head, tail, _ := strings.Cut(skeleton, summaryPlaceholder)
+		fmt.Print(head)
+		streamed := &countingWriter{w: os.Stdout}
+		summary, summaryErr = presentSummary(getSummary(ctx, provider, changes, streamed))
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		if streamed.n == 0 {
+			fmt.Print(summary)
+		}
+		fmt.Println(tail)
+		prSummary = head + summary + tail
+		printed = true
+	} else {
+		var streamTo io.Writer
+		if o.stream {
+			streamTo = os.Stderr
+		}
+		switch {
+		case *structured:
+			var err error
+			structuredSummary, err = getStructuredSummary(ctx, provider, changes)
+			if structuredSummary != nil {
+				summary = structuredSummary.markdown()
+			}
+			summary, summaryErr = presentSummary(summary, err)
+			if err := checkCancelled(ctx); err != nil {
+				return err
+			}
+		case !o.statsOnly:
+			summary, summaryErr = presentSummary(getSummary(ctx, provider, changes, streamTo))
+			if err := checkCancelled(ctx); err != nil {
+				return err
+			}
+		}
+
+		if o.format == "json" {
+			prSummary, err = renderJSON(jsonResult{
+				Branch:       currentBranch,
+				BaseBranch:   baseBranch,
+				Since:        *since,
+				Commits:      withCommitSummaries(parseCommits(commits, baseBranch != "" || *since != ""), commitNotes),
+				StatOverview: changesOverview,
+				Files:        fileStats,
+				Issues:       issues,
+				Summary:      summary,
+				Structured:   structuredSummary,
+				Model:        providerModel(o.providerName),
+			})
+		} else {
+			prSummary, err = render(summary)
+		}
+		if err != nil {
+			return fail(exitFailure, "Error: %v", err)
+		}
+	}
+
+	// Scripts using -quiet get no output at all when the summary failed
+	if quiet && summaryErr != nil {
+		return summaryErr
+	}
+
+	// The post-processed result is also what -create-pr opens the pull request with
+	var postErr error
+	if *injectInto == "" {
+		prSummary, postErr = applyPostProcess(o, prSummary)
+	} else if summaryErr == nil {
+		summary, postErr = applyPostProcess(o, summary)
+	}
+
+	if *injectInto != "" {
+		// Only the summary goes into the file, the template around it is the file's own
+		if summaryErr == nil {
+			if err := injectIntoFile(*injectInto, summary); err != nil {
+				return fail(exitFailure, "Error injecting summary: %v", err)
+			}
+			warnf("Summary injected into %s", *injectInto)
+		}
+	} else if err := deliver(o, prSummary, printed); err != nil {
+		return err
+	}
+	if summaryErr != nil {
+		if *createPR {
+			warnf("Not creating a pull request without a summary")
+		}
+		return summaryErr
+	}
+	if postErr != nil {
+		if *createPR {
+			warnf("Not creating a pull request without the post-processed summary")
+		}
+		return postErr
+	}
+
+	if *interactive && detailedDiff != "" {
+		// The conversation starts from the same prompt, rebuilt from the compression and embeddings caches
+		prompt, err := preparePrompt(ctx, changes)
+		if err != nil {
+			return err
+		}
+		return refineInteractively(ctx, conversation, prompt, summary, os.Stdin, os.Stdout, func(summary string) error {
+			if o.outputPath == "" {
+				return fmt.Errorf("no -output file to save to")
+			}
+			result, err := render(summary)
+			if err != nil {
+				return err
+			}
+			if err := writeOutput(o.outputPath, result, true); err != nil {
+				return err
+			}
+			warnf("Summary written to %s", o.outputPath)
+			return nil
+		})
+	}
+
+	if *createPR {
+		if structuredSummary != nil && !titleGiven {
+			*prTitle = structuredSummary.Title
+		}
+		url, err := createPullRequest(baseBranch, currentBranch, *prTitle, prSummary)
+		if err != nil {
+			return fail(exitAPI, "Error creating pull request: %v", err)
+		}
+		warnf("Pull request created: %s", url)
+	}
+	return nil
+}
+
+// runDiff implements the diff subcommand, summarizing a diff read from stdin or a -patch-file.
+// It doesn't need a git repository; the repository root is only used to find config and template files.
+func runDiff(ctx context.Context, args []string) error {
+	fs, o, err := newFlagSet("diff", "git diff | prgpt diff [flags], or prgpt diff -patch-file <path> [flags]")
+	if err != nil {
+		return err
+	}
+	addOutputFlags(fs, o)
+	patchFile := fs.String("patch-file", "", "read the diff from this patch file, e.g. git format-patch output, instead of stdin")
+	fs.Parse(args)
+
+	repoRoot, err := loadSettings(o)
+	if err != nil {
+		return err
+	}
+	if err := validateSettings(o); err != nil {
+		return err
+	}
+
+	var diff, commits string
+	if *patchFile != "" {
+		patch, err := readPatchFile(*patchFile)
+		if err != nil {
+			return fail(exitFailure, "Error: %v", err)
+		}
+		diff = patch
+		// Plain diffs have no commit metadata, which just leaves the commits empty
+		if commits, err = parsePatchCommits(patch); err != nil {
+			logf("No commits in %s: %v", *patchFile, err)
+		}
+	} else {
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return fail(exitFailure, "Error: prgpt diff reads the diff from stdin, e.g. git diff | prgpt diff")
+		}
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fail(exitFailure, "Error reading diff from stdin: %v", err)
+		}
+		diff = strings.TrimSpace(sanitizeText(string(input)))
+		if diff == "" {
+			return fail(exitFailure, "Error: no diff on stdin")
+		}
+	}
+
+	provider, err := setupProvider(ctx, o, repoRoot)
+	if err != nil {
+		return err
+	}
+
+	// No git log or git diff runs here, so the overview comes from the diff itself
+	stats := diffFileStats(diff)
+	addStackHint(repoRoot, stats)
+	if projectContext != "" {
+		appendSystemPrompt(projectContext)
+	}
+	changes := limitChangedLines(noteMoves(collapseBinaryFiles(ChangeSet{Commits: commits, Diff: diff, Overview: diffOverview(stats), Files: stats})))
+
+	if o.dryRun {
+		return printPrompt(ctx, changes)
+	}
+
+	var streamTo io.Writer
+	streamed := &countingWriter{w: os.Stdout}
+	if o.stream {
+		streamTo = os.Stderr
+		if o.outputPath == "" && o.format == "markdown" && !o.render && o.postProcess == "" {
+			streamTo = streamed
+		}
+	}
+	summary, summaryErr := getSummary(ctx, provider, changes, streamTo)
+	if err := checkCancelled(ctx); err != nil {
+		return err
+	}
+	if errors.Is(summaryErr, ErrEmptyDiff) {
+		return fail(exitFailure, "Error: %v", summaryErr)
+	}
+	if summaryErr != nil {
+		summary = summaryUnavailable
+	}
+
+	result := summary
+	if o.format == "json" {
+		result, err = renderJSON(jsonResult{
+			Commits:      parseCommits(commits, commits != ""),
+			StatOverview: changes.Overview,
+			Files:        stats,
+			Summary:      summary,
+			Model:        providerModel(o.providerName),
+		})
+		if err != nil {
+			return fail(exitFailure, "Error: %v", err)
+		}
+	}
+	printed := streamed.n > 0
+	if printed {
+		fmt.Println()
+	}
+	if quiet && summaryErr != nil {
+		return summaryErr
+	}
+	result, postErr := applyPostProcess(o, result)
+	if err := deliver(o, result, printed); err != nil {
+		return err
+	}
+	if summaryErr != nil {
+		return summaryErr
+	}
+	return postErr
+}
+
+// runConfig implements the config subcommand, printing the resolved configuration.
+func runConfig(args []string) error {
+	fs, o, err := newFlagSet("config", "prgpt config [flags]")
+	if err != nil {
+		return err
+	}
+	configFormat := fs.String("format", "table", "output format: table or json")
+	fs.Parse(args)
+
+	if _, err := loadSettings(o); err != nil {
+		return err
+	}
+	if err := printConfig(os.Stdout, *configFormat); err != nil {
+		return fail(exitFailure, "Error printing config: %v", err)
+	}
+	return nil
+}
+
+// printPrompt prints the prompt that would be sent to the summary provider for -dry-run.
+func printPrompt(ctx context.Context, changes ChangeSet) error {
+	prompt, err := buildPrompt(ctx, changes)
+	status.clear()
+	if err := checkCancelled(ctx); err != nil {
+		return err
+	}
+	if err != nil {
+		return fail(exitAPI, "Error building prompt: %w", err)
+	}
+	// The system prompt goes to stderr so the prompt itself can still be piped on
+	if systemPrompt != "" {
+		warnf("System prompt: %s\n", systemPrompt)
+	}
+	fmt.Println(stripCacheBreakpoint(prompt))
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// writeOutput writes content to path, creating parent directories as needed.
+// An existing file is only overwritten when force is set.
+func writeOutput(path, content string, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating directory: %v", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ChangeSet holds the git data a summary is generated from.
+type ChangeSet struct {
+	Commits   string
+	Diff      string
+	Overview  string
+	Files     []FileStat
+	TimeRange string   // set with -since, see promptData
+	Issues    []string // issue references found with -link-issues
+}
+
+// compression is the result of running changes through the Ollama compression and embeddings steps.
+type compression struct {
+	Content    string    `json:"-"`          // original content sent alongside the compressed summary
+	Compressed string    `json:"compressed"` // compressed summary of the changes
+	Embeddings []float64 `json:"embeddings"` // embeddings of the compressed summary
+	Raw        bool      `json:"-"`          // compression failed and Compressed holds the original content
+	Chunked    bool      `json:"chunked"`    // the diff was compressed in batches and Content only holds the overview
+}
+
+// compressionContent is the content the changes are compressed from, which the caches are keyed by.
+func compressionContent(changes ChangeSet) string {
+	return fmt.Sprintf("Detailed Changes:\n%s\n\nChanges Overview:\n%s", changes.Diff, changes.Overview)
+}
+
+// compressChanges compresses the changes and gets embeddings for the compressed content,
+// or with -embed-source raw for the original content while the compression runs.
+// Diffs larger than chunkThreshold are compressed in batches and only the overview is kept as content.
+// Results are reused from the on-disk cache when the same changes were compressed before.
+// With skipCompression the raw content is kept as is and nothing is cached.
+func compressChanges(ctx context.Context, changes ChangeSet) (compression, error) {
+	content := compressionContent(changes)
+	overviewOnly := fmt.Sprintf("Changes Overview:\n%s", changes.Overview)
+
+	if skipCompression {
+		c := compression{Content: content}
+		if !skipEmbeddings {
+			status.set("Getting embeddings from Ollama")
+			embeddings, err := getEmbeddings(ctx, content)
+			if err != nil {
+				return c, embeddingsFailed(ctx, err)
+			}
+			c.Embeddings = embeddings
+		}
+		return c, nil
+	}
+
+	cached, ok := loadCachedCompression(content)
+	if !ok {
+		if cached, ok = loadResumedCompression(content); ok {
+			logf("Resuming with the compression of the previous run, whose summary failed")
+		}
+	}
+	if ok && (skipEmbeddings || len(cached.Embeddings) > 0) {
+		logf("Using cached compression and embeddings")
+		cached.Content = content
+		if cached.Chunked {
+			cached.Content = overviewOnly
+		}
+		return cached, nil
+	}
+
+	c := compression{Content: content}
+
+	// Embeddings of the raw content don't depend on the compression, so compute them concurrently
+	var embeddings []float64
+	var embedErr error
+	var wg sync.WaitGroup
+	if embedSource == "raw" && !skipEmbeddings {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			embeddings, embedErr = getEmbeddings(ctx, content)
+		}()
+	}
+
+	status.set("Compressing with Ollama")
+	if len(changes.Diff) > chunkThreshold {
+		compressed, err := compressChunks(ctx, changes.Diff)
+		if err != nil {
+			wg.Wait()
+			return c, err
+		}
+		c.Compressed = compressed
+		c.Content = overviewOnly
+		c.Chunked = true
+	} else {
+		// First compress the logs
+		compressed, err := compressLogs(ctx, content)
+		switch {
+		case err != nil && failFastOnOllama:
+			wg.Wait()
+			return c, fmt.Errorf("error compressing logs: %w", ollamaUnavailable(err))
+		case err != nil:
+			warnf("Warning: could not compress with Ollama, sending the original content: %v", err)
+			compressed = content // Fallback to original content
+			c.Raw = true
+		case strings.TrimSpace(compressed) == "":
+			// An empty compression would leave the prompt without any changes
+			logf("Ollama returned an empty compression, using the original content")
+			compressed = content
+			c.Raw = true
+		}
+		c.Compressed = compressed
+	}
+	wg.Wait()
+
+	if !c.Raw {
+		// Kept until the summary succeeded, so a failed run can be repeated without compressing again
+		storeResumableCompression(content, c)
+	}
+	if skipEmbeddings {
+		return c, nil
+	}
+
+	if embedSource != "raw" {
+		// Get embeddings for the compressed content
+		status.set("Getting embeddings from Ollama")
+		embeddings, embedErr = getEmbeddings(ctx, c.Compressed)
+	}
+	if embedErr != nil {
+		return c, embeddingsFailed(ctx, embedErr)
+	}
+	c.Embeddings = embeddings
+
+	if !c.Raw {
+		storeCachedCompression(content, c)
+		storeResumableCompression(content, c)
+	}
+	return c, nil
+}
+
+// embeddingsFailed handles a failed embeddings step. It is an error with -fail-fast-on-ollama or
+// -no-embeddings=false and in a cancelled run, otherwise the embeddings are left out with a warning.
+func embeddingsFailed(ctx context.Context, err error) error {
+	if failFastOnOllama || requireEmbeddings || ctx.Err() != nil {
+		return fmt.Errorf("error getting embeddings: %w", ollamaUnavailable(err))
+	}
+	warnf("Warning: could not get embeddings from Ollama, continuing without them: %v", err)
+	return nil
+}
+
+// topFileStatsLimit is the number of most changed files listed in the FileStats prompt field.
+const topFileStatsLimit = 10
+
+// redactChanges returns changes with likely secrets redacted from the diff and their number,
+// unless -redact-secrets=false.
+func redactChanges(changes ChangeSet) (ChangeSet, int) {
+	if !redactSecrets {
+		return changes, 0
+	}
+	var redactions int
+	changes.Diff, redactions = redactText(changes.Diff)
+	return changes, redactions
+}
+
+// buildPrompt assembles the summarization prompt for the given changes.
+// Secrets are redacted from the diff first, unless -redact-secrets=false.
+// It compresses the changes and gets their embeddings, processes the embeddings,
+// and finally renders the prompt template with the processed embeddings and the original content.
+func buildPrompt(ctx context.Context, changes ChangeSet) (string, error) {
+	changes, redactions := redactChanges(changes)
+	if redactSecrets {
+		logf("Redacted %d possible secrets from the diff", redactions)
+	}
+
+	c, err := compressChanges(ctx, changes)
+	if err != nil {
+		return "", err
+	}
+
+	// Process embeddings
+	processedEmbeddings, err := processEmbeddings(c.Embeddings)
+	if err != nil {
+		return "", err
+	}
+	if processedEmbeddings == "" {
+		logf("Embeddings are empty, leaving them out of the prompt")
+	}
+
+	var groups string
+	if !skipEmbeddings && !skipFileGroups {
+		if groups, err = fileGroups(ctx, changes.Diff); err != nil {
+			if failFastOnOllama {
+				return "", fmt.Errorf("error grouping files: %w", ollamaUnavailable(err))
+			}
+			logf("Leaving out the file groups: %v", err)
+		}
+	}
+
+	data := promptData{
+		Diff:       c.Content,
+		Commits:    changes.Commits,
+		Embeddings: processedEmbeddings,
+		Compressed: c.Compressed,
+		TimeRange:  changes.TimeRange,
+		FileStats:  topFileStats(changes.Files, topFileStatsLimit),
+		FileGroups: groups,
+		Issues:     strings.Join(changes.Issues, ", "),
+	}
+	prompt, err := renderPrompt(promptTemplate, data)
+	if err != nil {
+		return "", err
+	}
+
+	excess, err := checkTokenBudget(prompt)
+	if err != nil || excess == 0 {
+		return prompt, err
+	}
+	if c.Chunked {
+		warnf("Warning: chunked prompt can't be truncated further")
+		return prompt, nil
+	}
+
+	// Trim only the detailed diff, keeping the overview and commit list intact
+	if c.Raw {
+		excess = (excess + 1) / 2 // the raw diff appears twice
+	}
+	diff := truncateDiff(changes.Diff, len(changes.Diff)-excess)
+	data.Diff = fmt.Sprintf("Detailed Changes:\n%s\n\nChanges Overview:\n%s", diff, changes.Overview)
+	if c.Raw {
+		data.Compressed = data.Diff
+	}
+	return renderPrompt(promptTemplate, data)
+}
+
+// summaryUnavailable is printed in place of the summary when it could not be generated.
+const summaryUnavailable = "Unable to generate summary"
+
+// presentSummary returns what the output shows for the result of getSummary: the summary, nothing
+// for changes without file changes, or summaryUnavailable along with the error.
+func presentSummary(summary string, err error) (string, error) {
+	switch {
+	case errors.Is(err, ErrEmptyDiff):
+		return "", nil
+	case err != nil:
+		return summaryUnavailable, err
+	}
+	return summary, nil
+}
+
+// preparePrompt builds the prompt sent to the summary provider for changes.
+func preparePrompt(ctx context.Context, changes ChangeSet) (string, error) {
+	prompt, err := buildPrompt(ctx, changes)
+	if err != nil {
+		return "", fail(exitAPI, "Error building prompt: %w", err)
+	}
+	logf("Prompt size: %d characters, system prompt: %q", len(prompt), systemPrompt)
+	if !promptCaching {
+		prompt = stripCacheBreakpoint(prompt)
+	}
+	return prompt, nil
+}
+
+// getSummary generates a summary of the given content using the selected summary provider.
+// When streamTo is set and the provider supports it, the summary is also written there as it is generated.
+// It returns ErrEmptyDiff when there are no file changes, and exitAPI errors wrapping the errors of
+// the provider and Ollama otherwise.
+func getSummary(ctx context.Context, provider SummaryProvider, changes ChangeSet, streamTo io.Writer) (string, error) {
+	if changes.Diff == "" {
+		return "", ErrEmptyDiff
+	}
+	if offline, ok := provider.(offlineProvider); ok {
+		summary := offline.summarizeChanges(changes)
+		if streamTo != nil {
+			fmt.Fprint(streamTo, summary)
+		}
+		return summary, nil
+	}
+	defer status.clear()
+	prompt, err := preparePrompt(ctx, changes)
+	if err != nil {
+		return "", err
+	}
+
+	var summary string
+	done := metrics.track(phaseSummary)
+	if streaming, ok := provider.(StreamingProvider); ok && streamTo != nil {
+		// The streamed summary shows the progress itself
+		status.clear()
+		summary, err = streaming.SummarizeStream(ctx, prompt, streamTo)
+	} else {
+		status.set("Generating summary")
+		summary, err = provider.Summarize(ctx, prompt)
+	}
+	done()
+	if err != nil {
+		return "", fail(exitAPI, "Error generating summary: %w", err)
+	}
+	metrics.addEstimate(prompt, summary)
+	summaryDone(changes)
+
+	return summary, nil
+}
+
+// summaryDone cleans up after the summary of changes was generated.
+func summaryDone(changes ChangeSet) {
+	if !skipCompression {
+		// The compression doesn't need to be resumed from anymore
+		redacted, _ := redactChanges(changes)
+		clearResumableCompression(compressionContent(redacted))
+	}
+}
@@ -0,0 +1,49 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands lists the clipboard utilities to try on each platform, in order of preference.
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// copyToClipboard places text on the system clipboard using the first clipboard utility that
+// works, so e.g. xclip is still tried when wl-copy is installed but there is no Wayland session.
+func copyToClipboard(text string) error {
+	candidates, ok := clipboardCommands[runtime.GOOS]
+	if !ok {
+		candidates = clipboardCommands["linux"]
+	}
+
+	var failures []error
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate[0])
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(path, candidate[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			failures = append(failures, fmt.Errorf("%s failed: %v: %s", candidate[0], err, strings.TrimSpace(string(output))))
+			continue
+		}
+		return nil
+	}
+	if len(failures) > 0 {
+		return errors.Join(failures...)
+	}
+	return errors.New("no clipboard utility found (install pbcopy, wl-copy, xclip or xsel)")
+}
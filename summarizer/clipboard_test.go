@@ -0,0 +1,89 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeClipboardCommands makes copyToClipboard try the given scripts, found in a temporary PATH.
+func fakeClipboardCommands(t *testing.T, scripts map[string]string, order ...string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake clipboard utilities are shell scripts")
+	}
+
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	var candidates [][]string
+	for _, name := range order {
+		candidates = append(candidates, []string{name})
+	}
+	original := clipboardCommands[runtime.GOOS]
+	clipboardCommands[runtime.GOOS] = candidates
+	t.Cleanup(func() { clipboardCommands[runtime.GOOS] = original })
+	return dir
+}
+
+// copyScript is a fake clipboard utility writing its stdin to its own path with .out appended,
+// using shell builtins only since PATH holds nothing but the fakes.
+const copyScript = `IFS= read -r line; printf '%s' "$line" > "$0.out"`
+
+func TestCopyToClipboard(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts map[string]string
+		wantErr []string
+		copied  string // script whose copy of stdin must exist
+	}{
+		{
+			name:    "first utility fails",
+			scripts: map[string]string{"wl-copy": "echo 'no Wayland session' >&2; exit 1", "xclip": copyScript},
+			copied:  "xclip",
+		},
+		{
+			name:    "missing utilities skipped",
+			scripts: map[string]string{"xsel": copyScript},
+			copied:  "xsel",
+		},
+		{
+			name:    "all fail",
+			scripts: map[string]string{"wl-copy": "echo 'no Wayland session' >&2; exit 1", "xclip": "echo 'no display' >&2; exit 1"},
+			wantErr: []string{"wl-copy failed", "no Wayland session", "xclip failed", "no display"},
+		},
+		{
+			name:    "none installed",
+			wantErr: []string{"no clipboard utility found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fakeClipboardCommands(t, tt.scripts, "wl-copy", "xclip", "xsel")
+
+			err := copyToClipboard("summary")
+			if len(tt.wantErr) > 0 {
+				for _, want := range tt.wantErr {
+					if err == nil || !strings.Contains(err.Error(), want) {
+						t.Errorf("copyToClipboard() error = %v, want it to contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copyToClipboard() error = %v", err)
+			}
+			if got, err := os.ReadFile(filepath.Join(dir, tt.copied+".out")); err != nil || string(got) != "summary" {
+				t.Errorf("%s got %q, %v, want %q", tt.copied, got, err, "summary")
+			}
+		})
+	}
+}
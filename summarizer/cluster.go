@@ -0,0 +1,162 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// skipFileGroups disables grouping the changed files by the similarity of their embeddings.
+var skipFileGroups bool
+
+// File grouping limits: diffs with more files are left ungrouped, since every file costs an
+// embeddings call, and each file's diff is embedded up to fileEmbeddingChars characters.
+const (
+	maxGroupedFiles    = 40
+	fileEmbeddingChars = 4000
+)
+
+// fileGroupThreshold is the cosine similarity a file needs to the centroid of a group to join it.
+const fileGroupThreshold = 0.8
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when their lengths
+// differ or either of them is a zero vector.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, magA, magB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		magA += a[i] * a[i]
+		magB += b[i] * b[i]
+	}
+	if magA == 0 || magB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(magA) * math.Sqrt(magB))
+}
+
+// fileGroup is a set of changed files whose diffs have similar embeddings.
+type fileGroup struct {
+	paths    []string
+	centroid []float64
+	members  [][]float64 // embeddings of paths, in the same order
+}
+
+// add puts a file into the group and moves the centroid to the mean of the members.
+func (g *fileGroup) add(path string, embedding []float64) {
+	g.paths = append(g.paths, path)
+	g.members = append(g.members, embedding)
+	g.centroid = make([]float64, len(embedding))
+	for _, member := range g.members {
+		for i, v := range member {
+			g.centroid[i] += v / float64(len(g.members))
+		}
+	}
+}
+
+// groupByEmbedding groups files greedily: each file joins the group whose centroid it is most similar
+// to, if that similarity reaches threshold, and starts a new group otherwise. Groups are returned
+// largest first, with the files of a group ordered by their similarity to its centroid.
+func groupByEmbedding(paths []string, embeddings [][]float64, threshold float64) [][]string {
+	var groups []*fileGroup
+	for i, path := range paths {
+		var best *fileGroup
+		bestSimilarity := threshold
+		for _, g := range groups {
+			if similarity := cosineSimilarity(embeddings[i], g.centroid); similarity >= bestSimilarity {
+				best, bestSimilarity = g, similarity
+			}
+		}
+		if best == nil {
+			best = &fileGroup{}
+			groups = append(groups, best)
+		}
+		best.add(path, embeddings[i])
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].paths) > len(groups[j].paths) })
+	result := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		order := make([]int, len(g.paths))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return cosineSimilarity(g.members[order[i]], g.centroid) > cosineSimilarity(g.members[order[j]], g.centroid)
+		})
+		sorted := make([]string, len(order))
+		for i, idx := range order {
+			sorted[i] = g.paths[idx]
+		}
+		result = append(result, sorted)
+	}
+	return result
+}
+
+// commonDir returns the deepest directory shared by all paths, or "" when they have none in common.
+func commonDir(paths []string) string {
+	dir := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for dir != "." && dir != "/" && p != dir && !strings.HasPrefix(p, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// fileGroups embeds the diff of every changed file and lists the files grouped by similarity,
+// one group per line, e.g. "- internal/auth: internal/auth/login.go, internal/auth/token.go".
+// It returns "" when there are fewer than two or more than maxGroupedFiles files.
+func fileGroups(ctx context.Context, diff string) (string, error) {
+	var paths []string
+	var embeddings [][]float64
+	chunks := splitDiffByFile(diff)
+	if len(chunks) < 2 || len(chunks) > maxGroupedFiles {
+		return "", nil
+	}
+
+	status.set("Grouping files with Ollama embeddings")
+	for _, chunk := range chunks {
+		if !strings.HasPrefix(chunk, "diff --git ") {
+			continue
+		}
+		if len(chunk) > fileEmbeddingChars {
+			chunk = chunk[:fileEmbeddingChars]
+		}
+		embedding, err := getEmbeddings(ctx, chunk)
+		if err != nil {
+			return "", fmt.Errorf("error getting file embeddings: %v", err)
+		}
+		paths = append(paths, diffFilePath(chunk))
+		embeddings = append(embeddings, embedding)
+	}
+	if len(paths) < 2 {
+		return "", nil
+	}
+
+	groups := groupByEmbedding(paths, embeddings, fileGroupThreshold)
+	if len(groups) == len(paths) {
+		logf("No two changed files are similar enough to group")
+	}
+	lines := make([]string, 0, len(groups))
+	for _, group := range groups {
+		label := commonDir(group)
+		switch {
+		case len(group) == 1:
+			lines = append(lines, "- "+group[0])
+		case label == "":
+			lines = append(lines, "- related files: "+strings.Join(group, ", "))
+		default:
+			lines = append(lines, fmt.Sprintf("- %s: %s", label, strings.Join(group, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
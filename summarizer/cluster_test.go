@@ -0,0 +1,44 @@
+package summarizer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{name: "identical", a: []float64{1, 2, 3}, b: []float64{1, 2, 3}, want: 1},
+		{name: "scaled", a: []float64{1, 2}, b: []float64{2, 4}, want: 1},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 1}, want: 0},
+		{name: "opposite", a: []float64{1, 0}, b: []float64{-1, 0}, want: -1},
+		{name: "zero vector", a: []float64{0, 0}, b: []float64{1, 1}, want: 0},
+		{name: "length mismatch", a: []float64{1}, b: []float64{1, 1}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupByEmbedding(t *testing.T) {
+	paths := []string{"auth/login.go", "ui/button.tsx", "auth/token.go", "README.md"}
+	embeddings := [][]float64{{1, 0, 0}, {0, 1, 0}, {0.9, 0.1, 0}, {0, 0, 1}}
+
+	got := groupByEmbedding(paths, embeddings, fileGroupThreshold)
+	want := [][]string{{"auth/login.go", "auth/token.go"}, {"ui/button.tsx"}, {"README.md"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groupByEmbedding() = %v, want %v", got, want)
+	}
+	if dir := commonDir(got[0]); dir != "auth" {
+		t.Fatalf("commonDir() = %q, want %q", dir, "auth")
+	}
+}
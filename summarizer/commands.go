@@ -0,0 +1,85 @@
+package summarizer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// command is a prgpt subcommand. Commands built on pr run it with preset flags, which the
+// arguments can add to.
+type command struct {
+	names   []string // the name and its aliases
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// commands returns the subcommands in the order the usage lists them. Running prgpt without a
+// subcommand is the same as prgpt pr.
+func commands() []command {
+	return []command{
+		{names: []string{"pr", "summarize"}, summary: "summarize the commits of the current branch for a pull request (default)", run: runPR},
+		{names: []string{"title"}, summary: "print a one-line conventional-commit style PR title (pr -title-only)", run: withFlags(runPR, "-title-only")},
+		{names: []string{"commit-msg"}, summary: "print a one-line commit message for the staged changes (pr -staged -title-only)", run: withFlags(runPR, "-staged", "-title-only")},
+		{names: []string{"diff"}, summary: "summarize a diff read from stdin", run: runDiff},
+		{names: []string{"config"}, summary: "print the resolved configuration", run: func(ctx context.Context, args []string) error { return runConfig(args) }},
+		{names: []string{"install-hook"}, summary: "add prgpt to the prepare-commit-msg or pre-push hook of the repository", run: func(ctx context.Context, args []string) error { return runInstallHook(args) }},
+		{names: []string{"uninstall-hook"}, summary: "remove prgpt from the hook again", run: func(ctx context.Context, args []string) error { return runUninstallHook(args) }},
+	}
+}
+
+// withFlags returns run with flags passed ahead of the arguments.
+func withFlags(run func(ctx context.Context, args []string) error, flags ...string) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		return run(ctx, append(append([]string{}, flags...), args...))
+	}
+}
+
+// findCommand returns the subcommand called name, reporting false when there is none.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		for _, n := range cmd.names {
+			if n == name {
+				return cmd, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+// commandAfterFlags returns the subcommand named by the first argument after the flags fs parsed
+// from args, as in prgpt -v title, along with args without it. A "--" before the argument escapes
+// it, so prgpt -- title compares against a branch called title.
+func commandAfterFlags(fs *flag.FlagSet, args []string) (command, []string, bool) {
+	i := len(args) - fs.NArg()
+	if fs.NArg() == 0 || i == 0 || args[i-1] == "--" {
+		return command{}, nil, false
+	}
+	cmd, ok := findCommand(args[i])
+	if !ok {
+		return command{}, nil, false
+	}
+	return cmd, append(append([]string{}, args[:i]...), args[i+1:]...), true
+}
+
+// usage describes the subcommands and exit codes.
+func usage() string {
+	var b strings.Builder
+	b.WriteString("Usage: prgpt [flags] [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(&b, "  %-15s %s\n", strings.Join(cmd.names, ", "), cmd.summary)
+	}
+	b.WriteString(`
+Run prgpt <command> -h for the flags of a command. Without a command prgpt runs pr, whose
+argument is the base branch; use prgpt -- <base> for a base branch named like a command.
+
+Exit codes:
+  0    success
+  1    git or other error
+  2    missing configuration or credentials
+  3    API failure
+  130  cancelled
+`)
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package summarizer
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantOK bool
+	}{
+		{name: "pr", wantOK: true},
+		{name: "summarize", wantOK: true},
+		{name: "commit-msg", wantOK: true},
+		{name: "main", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := findCommand(tt.name); ok != tt.wantOK {
+				t.Fatalf("findCommand(%q) ok = %v, want %v", tt.name, ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWithFlags(t *testing.T) {
+	var got []string
+	run := withFlags(func(ctx context.Context, args []string) error {
+		got = args
+		return nil
+	}, "-staged", "-title-only")
+
+	if err := run(context.Background(), []string{"-provider", "mock"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if want := []string{"-staged", "-title-only", "-provider", "mock"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("args = %q, want %q", got, want)
+	}
+}
+
+func TestCommandAfterFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCmd  string
+		wantRest []string
+	}{
+		{name: "bool flag before the command", args: []string{"-v", "pr"}, wantCmd: "pr", wantRest: []string{"-v"}},
+		{name: "flag value before the command", args: []string{"-provider", "mock", "title", "-model", "m"}, wantCmd: "title", wantRest: []string{"-provider", "mock", "-model", "m"}},
+		{name: "flag value named like a command", args: []string{"-provider", "diff"}},
+		{name: "base branch after the flags", args: []string{"-v", "main"}},
+		{name: "base branch named like a command escaped", args: []string{"-v", "--", "title"}},
+		{name: "base branch escaped without flags", args: []string{"--", "config"}},
+		{name: "positional argument first", args: []string{"main", "title"}},
+		{name: "only flags", args: []string{"-v"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("prgpt test", flag.ContinueOnError)
+			fs.Bool("v", false, "")
+			fs.String("provider", "", "")
+			fs.String("model", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd, rest, ok := commandAfterFlags(fs, tt.args)
+			if ok != (tt.wantCmd != "") {
+				t.Fatalf("commandAfterFlags(%q) ok = %v, want %v", tt.args, ok, tt.wantCmd != "")
+			}
+			if ok && (cmd.names[0] != tt.wantCmd || !reflect.DeepEqual(rest, tt.wantRest)) {
+				t.Errorf("commandAfterFlags(%q) = %s, %q, want %s, %q", tt.args, cmd.names[0], rest, tt.wantCmd, tt.wantRest)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// compareConcurrency is the number of summaries -compare generates at the same time.
+const compareConcurrency = 3
+
+// compareTarget is a provider and model -compare generates a summary with.
+type compareTarget struct {
+	provider string
+	model    string // empty for the provider's configured model
+}
+
+// label names the target in the headings of the comparison.
+func (t compareTarget) label() string {
+	model := t.model
+	if model == "" {
+		model = providerModel(t.provider)
+	}
+	return fmt.Sprintf("%s (%s)", t.provider, model)
+}
+
+// parseCompareTargets parses a -compare list such as "anthropic,openai:gpt-4o,gemini". Each entry is a
+// provider, optionally followed by a colon and the model to use instead of the provider's configured one.
+func parseCompareTargets(spec string) ([]compareTarget, error) {
+	var targets []compareTarget
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Only the first colon separates the model, Bedrock model IDs contain one too
+		provider, model, _ := strings.Cut(entry, ":")
+		targets = append(targets, compareTarget{provider: provider, model: model})
+	}
+	if len(targets) < 2 {
+		return nil, fmt.Errorf("needs at least two providers or models to compare, e.g. anthropic,openai")
+	}
+	return targets, nil
+}
+
+// newTargetProvider returns the summary provider of target, using its model if one is given.
+func newTargetProvider(target compareTarget) (SummaryProvider, error) {
+	provider, err := newProvider(target.provider)
+	if err != nil || target.model == "" {
+		return provider, err
+	}
+	switch p := provider.(type) {
+	case *anthropicProvider:
+		p.model = target.model
+	case *openAIProvider:
+		if p.azure {
+			return nil, fmt.Errorf("azure: the model is set by -deployment, leave it out of -compare")
+		}
+		p.model = target.model
+	case *geminiProvider:
+		p.model = target.model
+	case *bedrockProvider:
+		p.model = target.model
+	case *ollamaProvider:
+		p.model = target.model
+	}
+	return provider, nil
+}
+
+// compareResult is the summary of one -compare target, or the error generating it.
+type compareResult struct {
+	target  compareTarget
+	summary string
+	err     error
+}
+
+// compareSummaries sends the prompt for changes to every target, at most compareConcurrency at a
+// time, and returns the results in the order of targets. The prompt is built only once.
+func compareSummaries(ctx context.Context, targets []compareTarget, providers []SummaryProvider, changes ChangeSet) ([]compareResult, error) {
+	defer status.clear()
+	prompt, err := preparePrompt(ctx, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	status.set(fmt.Sprintf("Generating %d summaries", len(targets)))
+	results := make([]compareResult, len(targets))
+	slots := make(chan struct{}, compareConcurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			logf("Comparing: generating summary with %s", target.label())
+			var summary string
+			var err error
+			if offline, ok := providers[i].(offlineProvider); ok {
+				summary = offline.summarizeChanges(changes)
+			} else {
+				done := metrics.track(phaseSummary)
+				summary, err = providers[i].Summarize(ctx, prompt)
+				done()
+				if err == nil {
+					metrics.addEstimate(prompt, summary)
+				}
+			}
+			results[i] = compareResult{target: target, summary: summary, err: err}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// renderComparison lays out the compared summaries under a heading per target.
+func renderComparison(results []compareResult) string {
+	var b strings.Builder
+	for i, result := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", result.target.label())
+		if result.err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", summaryUnavailable, result.err)
+			continue
+		}
+		b.WriteString(strings.TrimSpace(result.summary) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// comparisonError returns an exitAPI error when any of the compared summaries failed.
+func comparisonError(results []compareResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.err != nil {
+			failed = append(failed, result.target.label())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fail(exitAPI, "Error: %d of %d summaries failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
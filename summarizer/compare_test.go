@@ -0,0 +1,59 @@
+package summarizer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseCompareTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []compareTarget
+		wantErr bool
+	}{
+		{
+			name: "providers",
+			spec: "anthropic,openai",
+			want: []compareTarget{{provider: "anthropic"}, {provider: "openai"}},
+		},
+		{
+			name: "models",
+			spec: "openai:gpt-4o, openai:gpt-4o-mini",
+			want: []compareTarget{{provider: "openai", model: "gpt-4o"}, {provider: "openai", model: "gpt-4o-mini"}},
+		},
+		{
+			name: "Bedrock model ID with a colon",
+			spec: "bedrock:anthropic.claude-3-haiku-20240307-v1:0,anthropic",
+			want: []compareTarget{{provider: "bedrock", model: "anthropic.claude-3-haiku-20240307-v1:0"}, {provider: "anthropic"}},
+		},
+		{name: "single target", spec: "anthropic,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCompareTargets(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCompareTargets(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCompareTargets(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderComparison(t *testing.T) {
+	results := []compareResult{
+		{target: compareTarget{provider: "openai", model: "gpt-4o"}, summary: "Adds a cache.\n"},
+		{target: compareTarget{provider: "gemini", model: "gemini-1.5-pro"}, err: errors.New("quota exceeded")},
+	}
+	want := "## openai (gpt-4o)\n\nAdds a cache.\n\n## gemini (gemini-1.5-pro)\n\nUnable to generate summary: quota exceeded"
+	if got := renderComparison(results); got != want {
+		t.Errorf("renderComparison() = %q, want %q", got, want)
+	}
+	if err := comparisonError(results); exitCode(err) != exitAPI {
+		t.Errorf("comparisonError() = %v, want an exitAPI error", err)
+	}
+}
@@ -0,0 +1,225 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Sources a configuration value can be resolved from.
+const (
+	sourceDefault = "default"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceFlag    = "flag"
+)
+
+// setting is a single resolved configuration value and where it came from.
+type setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// envOr returns the value of the environment variable, or def when it is unset or empty.
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt returns the integer value of the environment variable, or def when it is unset or empty.
+func envInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+// maskSecret hides all but the last four characters of a secret value.
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// envSetting resolves a setting from an environment variable, falling back to a default.
+func envSetting(key, env, def string, secret bool) setting {
+	value, source := def, sourceDefault
+	if v, ok := os.LookupEnv(env); ok {
+		value, source = v, sourceEnv+" "+env
+	}
+	if secret {
+		value = maskSecret(value)
+	}
+	return setting{Key: key, Value: value, Source: source}
+}
+
+// flagSetting resolves a setting from a command line flag.
+func flagSetting(name string) setting {
+	source := sourceDefault
+	cmdFlags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			source = sourceFlag + " -" + name
+		}
+	})
+	key := strings.ReplaceAll(name, "-", "_")
+	if path, ok := configFileSources[key]; ok && source == sourceDefault {
+		source = sourceFile + " " + path
+	}
+	return setting{Key: key, Value: cmdFlags.Lookup(name).Value.String(), Source: source}
+}
+
+// secretFlagSetting resolves a setting from a command line flag holding a secret, which is masked.
+func secretFlagSetting(name string) setting {
+	s := flagSetting(name)
+	s.Value = maskSecret(s.Value)
+	return s
+}
+
+// valueSetting reports a setting that can only come from a config file or the built-in default.
+func valueSetting(key, value string) setting {
+	source := sourceDefault
+	if path, ok := configFileSources[key]; ok {
+		source = sourceFile + " " + path
+	}
+	return setting{Key: key, Value: value, Source: source}
+}
+
+// flagEnvSetting resolves a setting from a command line flag with an environment variable fallback.
+func flagEnvSetting(name, env string) setting {
+	s := flagSetting(name)
+	if s.Source == sourceDefault {
+		if _, ok := os.LookupEnv(env); ok {
+			s.Source = sourceEnv + " " + env
+		}
+	}
+	return s
+}
+
+// apiURLSetting reports the API URL of provider, which -api-base or the api_base config key
+// replaces for the selected provider.
+func apiURLSetting(key, provider, url string) setting {
+	s := setting{Key: key, Value: url, Source: sourceDefault}
+	if apiBase != "" && cmdFlags.Lookup("provider").Value.String() == provider {
+		s.Source = flagSetting("api-base").Source
+	}
+	return s
+}
+
+// maxSettingLength is the number of bytes of a long value, such as the system prompt, that
+// -print-config shows.
+const maxSettingLength = 60
+
+// shortenedSetting resolves a setting from a command line flag whose value can be long text,
+// shown on a single line and cut to maxSettingLength.
+func shortenedSetting(name string) setting {
+	s := flagSetting(name)
+	value := strings.Join(strings.Fields(s.Value), " ")
+	if len(value) > maxSettingLength {
+		value = value[:runeBoundary(value, maxSettingLength)] + "..."
+	}
+	s.Value = value
+	return s
+}
+
+// resolvedSettings returns the effective configuration with secrets masked.
+func resolvedSettings() []setting {
+	return []setting{
+		flagSetting("provider"),
+		flagSetting("local"),
+		secretFlagSetting("api-key"),
+		flagSetting("api-key-file"),
+		envSetting("anthropic_api_key", "ANTHROPIC_API_KEY", "", true),
+		apiURLSetting("anthropic_api_url", "anthropic", anthropicAPIURL),
+		flagEnvSetting("model", "PRGPT_MODEL"),
+		flagEnvSetting("max-tokens", "PRGPT_MAX_TOKENS"),
+		flagSetting("temperature"),
+		flagSetting("top-p"),
+		envSetting("openai_api_key", "OPENAI_API_KEY", "", true),
+		apiURLSetting("openai_api_url", "openai", openAIAPIURL),
+		flagSetting("openai-model"),
+		envSetting("azure_openai_key", "AZURE_OPENAI_KEY", "", true),
+		envSetting("azure_openai_endpoint", "AZURE_OPENAI_ENDPOINT", "", false),
+		flagEnvSetting("deployment", "AZURE_OPENAI_DEPLOYMENT"),
+		flagSetting("api-version"),
+		envSetting("gemini_api_key", "GEMINI_API_KEY", "", true),
+		apiURLSetting("gemini_api_url", "gemini", geminiAPIURL),
+		flagSetting("gemini-model"),
+		envSetting("aws_access_key_id", "AWS_ACCESS_KEY_ID", "", true),
+		envSetting("aws_profile", "AWS_PROFILE", "default", false),
+		flagEnvSetting("region", "AWS_REGION"),
+		flagSetting("bedrock-model"),
+		flagEnvSetting("ollama-url", "OLLAMA_HOST"),
+		flagEnvSetting("embed-model", "PRGPT_EMBED_MODEL"),
+		flagEnvSetting("compress-model", "PRGPT_COMPRESS_MODEL"),
+		flagEnvSetting("ollama-model", "PRGPT_OLLAMA_MODEL"),
+		flagEnvSetting("timeout", "PRGPT_TIMEOUT"),
+		flagSetting("exclude"),
+		flagSetting("base-candidate"),
+		flagSetting("issue-pattern"),
+		flagSetting("chunk-threshold"),
+		flagSetting("max-lines-per-file"),
+		flagSetting("max-input-tokens"),
+		flagSetting("on-overflow"),
+		flagSetting("prompt-template"),
+		shortenedSetting("system-prompt"),
+		flagSetting("pr-template"),
+		flagSetting("platform"),
+		flagSetting("max-retries"),
+		flagSetting("max-retry-wait"),
+		flagSetting("no-compress"),
+		flagSetting("no-embeddings"),
+		flagSetting("fail-fast-on-ollama"),
+		flagSetting("no-file-groups"),
+		flagSetting("no-stack-hint"),
+		flagSetting("stack-ext"),
+		flagSetting("context-file"),
+		flagSetting("embed-source"),
+		flagSetting("embed-encoding"),
+		flagSetting("redact-secrets"),
+		flagSetting("no-cache"),
+		flagSetting("prompt-cache"),
+		flagSetting("header"),
+		flagSetting("api-base"),
+		flagSetting("verbose"),
+		flagSetting("metrics"),
+		flagSetting("strict-json"),
+	}
+}
+
+// printConfig writes the resolved configuration to w either as a table or as JSON.
+func printConfig(w io.Writer, format string) error {
+	settings := resolvedSettings()
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(settings)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+		for _, s := range settings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown config format %q (expected table or json)", format)
+	}
+}
@@ -0,0 +1,67 @@
+package summarizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIURLSetting(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		fileBase   bool // api_base comes from the user config file
+		provider   string
+		wantSource string
+	}{
+		{name: "default", provider: "anthropic", wantSource: sourceDefault},
+		{name: "flag", args: []string{"-api-base", "http://gateway.example"}, provider: "anthropic", wantSource: "flag -api-base"},
+		{name: "config file", fileBase: true, provider: "anthropic", wantSource: "file config.toml"},
+		{name: "other provider", args: []string{"-api-base", "http://gateway.example"}, provider: "openai", wantSource: sourceDefault},
+	}
+
+	original := apiBase
+	t.Cleanup(func() { apiBase = original })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := withConfigFlags(t)
+			fs.String("provider", "anthropic", "")
+			fs.StringVar(&apiBase, "api-base", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if tt.fileBase {
+				if err := applyConfigValues("config.toml", map[string][]string{"api_base": {"http://gateway.example"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := apiURLSetting("api_url", tt.provider, "http://gateway.example/v1/messages")
+			if got.Source != tt.wantSource {
+				t.Errorf("apiURLSetting(%q).Source = %q, want %q", tt.provider, got.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestShortenedSetting(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "short", value: "Be brief.", want: "Be brief."},
+		{name: "multi-line", value: "Be brief.\n\nUse bullet points.", want: "Be brief. Use bullet points."},
+		{name: "long", value: strings.Repeat("word ", 20), want: strings.Repeat("word ", 12)[:maxSettingLength] + "..."},
+		{name: "long with multibyte characters", value: "a" + strings.Repeat("é", 40), want: "a" + strings.Repeat("é", (maxSettingLength-1)/2) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := withConfigFlags(t)
+			fs.String("system-prompt", tt.value, "")
+			if got := shortenedSetting("system-prompt"); got.Value != tt.want || got.Key != "system_prompt" {
+				t.Errorf("shortenedSetting() = %+v, want value %q", got, tt.want)
+			}
+		})
+	}
+}
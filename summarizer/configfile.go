@@ -0,0 +1,385 @@
+package summarizer
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Two config files are read, the first existing name of each:
+//
+//	$XDG_CONFIG_HOME/prgpt/config (or config.toml, config.yaml, config.yml), defaulting to ~/.config
+//	<repo root>/.prgpt.toml, .prgpt.yaml or .prgpt.yml
+//
+// Values of the repository file replace those of the user file, and lists of both add up.
+//
+// Both TOML ("key = value") and YAML ("key: value") syntax are accepted for flat keys, with lists
+// written inline ("[a, b]") or, in YAML, as "- item" lines below the key. Supported keys:
+//
+//	provider         summary provider (anthropic, openai, azure, gemini, bedrock, ollama or mock)
+//	local            true to generate the summary with Ollama too, like provider ollama
+//	model            Anthropic model used for the summary (anthropic_model is accepted too)
+//	max_tokens       maximum number of tokens in the Anthropic response
+//	temperature      sampling temperature of the summary model
+//	top_p            nucleus sampling top_p of the summary model
+//	openai_model     OpenAI model used with provider openai
+//	deployment       Azure OpenAI deployment used with provider azure
+//	api_version      Azure OpenAI API version used with provider azure
+//	gemini_model     Gemini model used with provider gemini
+//	bedrock_model    Bedrock model ID of the Claude model used with provider bedrock
+//	region           AWS region used with provider bedrock
+//	ollama_url       base URL of the Ollama server
+//	embed_model      Ollama model used for embeddings
+//	compress_model   Ollama model used to compress the diff
+//	ollama_model     Ollama model used for the summary with local or provider ollama
+//	timeout          timeout for each API request, e.g. "90s"
+//	max_retries      number of times to retry transient API failures
+//	max_retry_wait   longest wait a Retry-After header can ask for, e.g. "30s"
+//	chunk_threshold  diff size in characters above which the diff is compressed in chunks
+//	max_file_lines   number of changed lines kept of each file in the diff (0 keeps all)
+//	max_input_tokens estimated prompt size in tokens above which on_overflow applies
+//	on_overflow      warn, truncate or abort when the prompt is over max_input_tokens
+//	no_compress      true to send the raw diff without Ollama compression (more input tokens)
+//	embed_source     compressed or raw, what the embeddings are computed from
+//	embed_encoding   json or float32, how the embeddings are packed in the prompt
+//	no_embeddings    true to skip the embeddings step
+//	fail_fast_ollama true to abort when the Ollama compression or embeddings fail
+//	no_file_groups   true to skip grouping the changed files by embedding similarity
+//	no_stack_hint    true to leave the detected languages and build tools out of the system prompt
+//	context_files    list of files such as README.md sent as project background in the system prompt
+//	stack_extensions list of ".ext=Language" entries adding to the extensions the stack hint knows
+//	prompt_cache     true to use Anthropic prompt caching
+//	redact_secrets   false to send the diff without redacting likely secrets
+//	exclude          list of path globs to leave out of the diff
+//	issue_patterns   list of regular expressions matching issue references for -link-issues
+//	base_candidates  list of refs tried in order as the base branch when none is given
+//	prompt_template  file with a text/template summarization prompt
+//	system_prompt    system prompt sent to the summary provider separately from the changes
+//	pr_template      file with a text/template for the PR markdown
+//	no_merges        true to leave merge commits out of the commit list and diff of pr
+//	find_renames     similarity in percent at which pr pairs files as renames (0 disables)
+//	find_copies      true to also detect copied files in pr
+//	fetch            true to fetch the base branch from its remote before pr compares against it
+//	platform         github, gitlab, bitbucket or gitea, the platform the PR markdown is written for
+//	headers          list of "Name: Value" headers added to every summary provider request
+//	api_base         base URL of the summary provider API, e.g. an internal gateway
+//
+// Values are resolved with the precedence flags > env vars > config files > built-in defaults, so
+// an env var such as PRGPT_MODEL set for one run wins over the defaults a repository commits.
+//
+// api_base, headers, ollama_url, context_files and redact_secrets are only read from the user's
+// config file: in a repository config file they are ignored with a warning, since anyone who can
+// commit to the repository controls it.
+
+// configFileFlags maps config file keys to the flag they provide a value for.
+var configFileFlags = map[string]string{
+	"provider":         "provider",
+	"local":            "local",
+	"model":            "model",
+	"anthropic_model":  "model",
+	"max_tokens":       "max-tokens",
+	"temperature":      "temperature",
+	"top_p":            "top-p",
+	"openai_model":     "openai-model",
+	"deployment":       "deployment",
+	"api_version":      "api-version",
+	"gemini_model":     "gemini-model",
+	"bedrock_model":    "bedrock-model",
+	"region":           "region",
+	"ollama_url":       "ollama-url",
+	"embed_model":      "embed-model",
+	"compress_model":   "compress-model",
+	"ollama_model":     "ollama-model",
+	"timeout":          "timeout",
+	"max_retries":      "max-retries",
+	"max_retry_wait":   "max-retry-wait",
+	"chunk_threshold":  "chunk-threshold",
+	"max_file_lines":   "max-lines-per-file",
+	"max_input_tokens": "max-input-tokens",
+	"on_overflow":      "on-overflow",
+	"no_compress":      "no-compress",
+	"fail_fast_ollama": "fail-fast-on-ollama",
+	"no_embeddings":    "no-embeddings",
+	"no_file_groups":   "no-file-groups",
+	"no_stack_hint":    "no-stack-hint",
+	"stack_extensions": "stack-ext",
+	"context_files":    "context-file",
+	"find_renames":     "find-renames",
+	"find_copies":      "find-copies",
+	"fetch":            "fetch",
+	"headers":          "header",
+	"api_base":         "api-base",
+	"embed_encoding":   "embed-encoding",
+	"embed_source":     "embed-source",
+	"prompt_cache":     "prompt-cache",
+	"redact_secrets":   "redact-secrets",
+	"exclude":          "exclude",
+	"issue_patterns":   "issue-pattern",
+	"base_candidates":  "base-candidate",
+	"prompt_template":  "prompt-template",
+	"system_prompt":    "system-prompt",
+	"pr_template":      "pr-template",
+	"platform":         "platform",
+	"no_merges":        "no-merges",
+}
+
+// userOnlyConfigKeys are only read from the user's config file, never from the one in a repository:
+// a cloned repository could otherwise send the API key and the diff to a server of its choosing,
+// or files outside it to the provider.
+var userOnlyConfigKeys = map[string]bool{
+	"api_base":       true,
+	"headers":        true,
+	"ollama_url":     true,
+	"context_files":  true,
+	"redact_secrets": true,
+}
+
+// dropUserOnlyKeys removes the userOnlyConfigKeys from the values of the repository config file at
+// path, warning about each one.
+func dropUserOnlyKeys(path string, values map[string][]string) {
+	var dropped []string
+	for key := range values {
+		if userOnlyConfigKeys[key] {
+			dropped = append(dropped, key)
+			delete(values, key)
+		}
+	}
+	sort.Strings(dropped)
+	for _, key := range dropped {
+		warnf("Warning: ignoring %s in %s, it is only read from the user config file or flags", key, path)
+	}
+}
+
+// flagEnvVars maps flags to the environment variables overriding their config file value.
+var flagEnvVars = map[string][]string{
+	"model":          {"PRGPT_MODEL"},
+	"max-tokens":     {"PRGPT_MAX_TOKENS"},
+	"deployment":     {"AZURE_OPENAI_DEPLOYMENT"},
+	"region":         {"AWS_REGION", "AWS_DEFAULT_REGION"},
+	"ollama-url":     {"OLLAMA_HOST"},
+	"embed-model":    {"PRGPT_EMBED_MODEL"},
+	"compress-model": {"PRGPT_COMPRESS_MODEL"},
+	"ollama-model":   {"PRGPT_OLLAMA_MODEL"},
+	"timeout":        {"PRGPT_TIMEOUT"},
+}
+
+// envSet reports whether one of the environment variables of flag name is set, which makes the
+// flag default to it instead of the config file value.
+func envSet(name string) bool {
+	for _, env := range flagEnvVars[name] {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// configFileSources records which settings were taken from a config file, keyed by setting name.
+var configFileSources = map[string]string{}
+
+// configFileCandidates returns the user and repository config file paths to try, each in order
+// of preference.
+func configFileCandidates(repoRoot string) (user, repo []string) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		for _, name := range []string{"config", "config.toml", "config.yaml", "config.yml"} {
+			user = append(user, filepath.Join(configHome, "prgpt", name))
+		}
+	}
+
+	if repoRoot != "" {
+		for _, name := range []string{".prgpt.toml", ".prgpt.yaml", ".prgpt.yml"} {
+			repo = append(repo, filepath.Join(repoRoot, name))
+		}
+	}
+	return user, repo
+}
+
+// loadConfigFile reads the user config file and then the repository one, applying their values to
+// every flag that wasn't set on the command line or through its env var. It returns the paths of
+// the files used.
+func loadConfigFile(repoRoot string) ([]string, error) {
+	user, repo := configFileCandidates(repoRoot)
+	var used []string
+	for _, files := range []struct {
+		candidates []string
+		inRepo     bool
+	}{{user, false}, {repo, true}} {
+		path, err := loadFirstConfigFile(files.candidates, files.inRepo)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			used = append(used, path)
+		}
+	}
+	return used, nil
+}
+
+// loadFirstConfigFile applies the values of the first existing file of candidates and returns its
+// path, or "" if none exists. The userOnlyConfigKeys of a repository file are dropped.
+func loadFirstConfigFile(candidates []string, inRepo bool) (string, error) {
+	for _, path := range candidates {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		values, err := parseConfigFile(string(content))
+		if err != nil {
+			return "", fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if inRepo {
+			dropUserOnlyKeys(path, values)
+		}
+		if err := applyConfigValues(path, values); err != nil {
+			return "", fmt.Errorf("error in %s: %v", path, err)
+		}
+		return path, nil
+	}
+	return "", nil
+}
+
+// applyConfigValues sets the configuration from parsed config file values, leaving flags set
+// explicitly or through their env var alone.
+func applyConfigValues(path string, values map[string][]string) error {
+	setFlags := map[string]bool{}
+	cmdFlags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	for key, value := range values {
+		if len(value) > 1 && key != "exclude" && key != "base_candidates" && key != "issue_patterns" && key != "stack_extensions" && key != "context_files" && key != "headers" {
+			return fmt.Errorf("%s expects a single value", key)
+		}
+
+		name, ok := configFileFlags[key]
+		if !ok {
+			return fmt.Errorf("unknown key %q", key)
+		}
+		// Keys for flags of another subcommand, such as no_merges outside pr, don't apply here
+		if setFlags[name] || envSet(name) || cmdFlags.Lookup(name) == nil {
+			continue
+		}
+		for _, v := range value {
+			if err := cmdFlags.Lookup(name).Value.Set(v); err != nil {
+				return fmt.Errorf("invalid %s %q: %v", key, v, err)
+			}
+		}
+		// Record the source under the flag's key so aliases show up in -print-config
+		configFileSources[strings.ReplaceAll(name, "-", "_")] = path
+	}
+	return nil
+}
+
+// parseConfigFile parses the flat TOML/YAML subset described above into a map of key to values.
+func parseConfigFile(content string) (map[string][]string, error) {
+	values := map[string][]string{}
+	var listKey string
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+
+		// YAML block list item belonging to the previous key
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			if listKey == "" {
+				return nil, fmt.Errorf("line %d: list item without a key", lineNo)
+			}
+			values[listKey] = append(values[listKey], unquote(item))
+			continue
+		}
+		listKey = ""
+
+		key, value, ok := splitConfigLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\" or \"key: value\"", lineNo)
+		}
+
+		switch {
+		case value == "":
+			listKey = key
+			values[key] = nil
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, unquote(item))
+				}
+			}
+			values[key] = items
+		default:
+			values[key] = []string{unquote(value)}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for key, value := range values {
+		if len(value) == 0 {
+			return nil, fmt.Errorf("%s has no value", key)
+		}
+	}
+	return values, nil
+}
+
+// splitConfigLine splits a "key = value" or "key: value" line.
+func splitConfigLine(line string) (string, string, bool) {
+	end := strings.IndexFunc(line, func(r rune) bool {
+		return !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if end <= 0 {
+		return "", "", false
+	}
+
+	key := line[:end]
+	rest := strings.TrimSpace(line[end:])
+	if !strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, ":") {
+		return "", "", false
+	}
+	return strings.ReplaceAll(key, "-", "_"), strings.TrimSpace(rest[1:]), true
+}
+
+// stripComment removes a trailing "#" comment that isn't inside a quoted string.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips matching single or double quotes around a value.
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
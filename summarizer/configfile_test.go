@@ -0,0 +1,271 @@
+package summarizer
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// withConfigFlags makes cmdFlags a fresh flag set for the duration of the test.
+func withConfigFlags(t *testing.T) *flag.FlagSet {
+	t.Helper()
+
+	original, originalSources := cmdFlags, configFileSources
+	fs := flag.NewFlagSet("prgpt test", flag.ContinueOnError)
+	cmdFlags, configFileSources = fs, map[string]string{}
+	t.Cleanup(func() {
+		cmdFlags, configFileSources = original, originalSources
+	})
+	return fs
+}
+
+func TestLoadConfigFileIgnoresUserOnlyKeysInRepo(t *testing.T) {
+	tests := []struct {
+		name     string
+		inRepo   bool
+		wantBase string
+	}{
+		{name: "repository config file", inRepo: true, wantBase: ""},
+		{name: "user config file", inRepo: false, wantBase: "http://gateway.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := withConfigFlags(t)
+			base := fs.String("api-base", "", "")
+			model := fs.String("model", "", "")
+
+			repoRoot, configHome := t.TempDir(), t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+			path := filepath.Join(configHome, "prgpt", "config.toml")
+			if tt.inRepo {
+				path = filepath.Join(repoRoot, ".prgpt.toml")
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			content := "api_base = \"http://gateway.example\"\nmodel = \"claude\"\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := loadConfigFile(repoRoot); err != nil {
+				t.Fatalf("loadConfigFile() error = %v", err)
+			}
+			if *base != tt.wantBase {
+				t.Fatalf("api-base = %q, want %q", *base, tt.wantBase)
+			}
+			if *model != "claude" {
+				t.Fatalf("model = %q, want the other keys applied", *model)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFilePrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string
+		repo      string
+		env       string
+		wantModel string
+		wantFiles int
+	}{
+		{name: "user file", user: "model = user", wantModel: "user", wantFiles: 1},
+		{name: "repository file", repo: "model = repo", wantModel: "repo", wantFiles: 1},
+		{name: "repository file over user file", user: "model = user", repo: "model = repo", wantModel: "repo", wantFiles: 2},
+		{name: "env var over files", user: "model = user", repo: "model = repo", env: "env", wantModel: "env", wantFiles: 2},
+		{name: "no config file", wantModel: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRGPT_MODEL", tt.env)
+			fs := withConfigFlags(t)
+			model := fs.String("model", envOr("PRGPT_MODEL", "default"), "")
+
+			repoRoot, configHome := t.TempDir(), t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+			for path, content := range map[string]string{
+				filepath.Join(configHome, "prgpt", "config"): tt.user,
+				filepath.Join(repoRoot, ".prgpt.yaml"):       tt.repo,
+			} {
+				if content == "" {
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files, err := loadConfigFile(repoRoot)
+			if err != nil {
+				t.Fatalf("loadConfigFile() error = %v", err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("loadConfigFile() = %v, want %d files", files, tt.wantFiles)
+			}
+			if *model != tt.wantModel {
+				t.Errorf("model = %q, want %q", *model, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name:    "toml and yaml syntax",
+			content: "provider = \"openai\"\nmodel: claude\nmax-tokens = 2048\n",
+			want:    map[string][]string{"provider": {"openai"}, "model": {"claude"}, "max_tokens": {"2048"}},
+		},
+		{
+			name:    "inline list",
+			content: "exclude = [\"*.lock\", 'vendor/**', ]\n",
+			want:    map[string][]string{"exclude": {"*.lock", "vendor/**"}},
+		},
+		{
+			name:    "block list",
+			content: "---\nexclude:\n  - \"*.lock\"\n  - vendor/** # generated\nmodel: claude\n",
+			want:    map[string][]string{"exclude": {"*.lock", "vendor/**"}, "model": {"claude"}},
+		},
+		{
+			name:    "quoted value containing a hash",
+			content: "system_prompt = \"Use # headings\" # trailing comment\nissue_patterns = ['#\\d+']\n",
+			want:    map[string][]string{"system_prompt": {"Use # headings"}, "issue_patterns": {`#\d+`}},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# prgpt settings\n\nmodel = claude   # the default\n",
+			want:    map[string][]string{"model": {"claude"}},
+		},
+		{name: "list item without a key", content: "- vendor/**\n", wantErr: "line 1: list item without a key"},
+		{name: "line without a separator", content: "model claude\n", wantErr: "line 1: expected"},
+		{name: "key without a value", content: "model = claude\nexclude:\n", wantErr: "exclude has no value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfigFile(tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseConfigFile() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseConfigFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseConfigFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "model = claude # comment", want: "model = claude "},
+		{line: "# whole line", want: ""},
+		{line: `prompt = "a # b" # c`, want: `prompt = "a # b" `},
+		{line: `pattern = '#\d+'`, want: `pattern = '#\d+'`},
+		{line: `quote = "it's # fine"`, want: `quote = "it's # fine"`},
+		{line: "model = claude", want: "model = claude"},
+	}
+	for _, tt := range tests {
+		if got := stripComment(tt.line); got != tt.want {
+			t.Errorf("stripComment(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfigValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		values      map[string][]string
+		wantModel   string
+		wantExclude []string
+		wantSources map[string]string
+		wantErr     string
+	}{
+		{
+			name:        "file values",
+			values:      map[string][]string{"model": {"file-model"}, "exclude": {"*.lock", "vendor/**"}},
+			wantModel:   "file-model",
+			wantExclude: []string{"*.lock", "vendor/**"},
+			wantSources: map[string]string{"model": "config.toml", "exclude": "config.toml"},
+		},
+		{
+			name:        "flag over file",
+			args:        []string{"-model", "flag-model"},
+			values:      map[string][]string{"model": {"file-model"}},
+			wantModel:   "flag-model",
+			wantSources: map[string]string{},
+		},
+		{
+			name:        "alias recorded under its flag",
+			values:      map[string][]string{"anthropic_model": {"file-model"}},
+			wantModel:   "file-model",
+			wantSources: map[string]string{"model": "config.toml"},
+		},
+		{
+			name:        "key of another subcommand",
+			values:      map[string][]string{"no_merges": {"true"}},
+			wantModel:   "default",
+			wantSources: map[string]string{},
+		},
+		{name: "unknown key", values: map[string][]string{"modle": {"x"}}, wantErr: `unknown key "modle"`},
+		{name: "list for a single value", values: map[string][]string{"model": {"a", "b"}}, wantErr: "model expects a single value"},
+		{name: "invalid value", values: map[string][]string{"max_tokens": {"many"}}, wantErr: `invalid max_tokens "many"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRGPT_MODEL", "")
+			fs := withConfigFlags(t)
+			model := fs.String("model", "default", "")
+			fs.Int("max-tokens", 1024, "")
+			var exclude stringListFlag
+			fs.Var(&exclude, "exclude", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyConfigValues("config.toml", tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyConfigValues() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyConfigValues() error = %v", err)
+			}
+			if *model != tt.wantModel {
+				t.Errorf("model = %q, want %q", *model, tt.wantModel)
+			}
+			if !slices.Equal(exclude, tt.wantExclude) {
+				t.Errorf("exclude = %q, want %q", exclude, tt.wantExclude)
+			}
+			if !reflect.DeepEqual(configFileSources, tt.wantSources) {
+				t.Errorf("configFileSources = %v, want %v", configFileSources, tt.wantSources)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package summarizer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextFiles are the -context-file paths whose content is sent as background in the system prompt.
+var contextFiles stringListFlag
+
+// maxContextFileChars is the number of characters of each context file the system prompt includes.
+const maxContextFileChars = 4000
+
+// projectContext is the background text read from the context files by loadContextFiles.
+var projectContext string
+
+// loadContextFiles reads the context files into projectContext, each trimmed to maxContextFileChars.
+func loadContextFiles() error {
+	sections := make([]string, 0, len(contextFiles))
+	for _, path := range contextFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		text, truncated := truncateContext(strings.TrimSpace(sanitizeText(string(content))), maxContextFileChars)
+		if truncated > 0 {
+			logf("Context file %s truncated to %d characters", path, maxContextFileChars)
+			text += fmt.Sprintf("\n[... %d more characters left out]", truncated)
+		}
+		sections = append(sections, fmt.Sprintf("<context file=%q>\n%s\n</context>", path, text))
+	}
+	if len(sections) > 0 {
+		projectContext = "Background on the project, to match its terminology and voice:\n\n" + strings.Join(sections, "\n\n")
+	}
+	return nil
+}
+
+// truncateContext cuts text to at most limit bytes and returns the number of bytes left out. It cuts
+// at the last line break before the limit, unless that keeps less than half of it, and otherwise
+// after the last complete character.
+func truncateContext(text string, limit int) (string, int) {
+	if len(text) <= limit {
+		return text, 0
+	}
+	cut := strings.LastIndexByte(text[:limit], '\n')
+	if cut < limit/2 {
+		cut = limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimRight(text[:cut], "\n"), len(text) - cut
+}
+
+// appendSystemPrompt adds a paragraph to the system prompt.
+func appendSystemPrompt(text string) {
+	if systemPrompt == "" {
+		systemPrompt = text
+		return
+	}
+	systemPrompt += "\n\n" + text
+}
@@ -0,0 +1,30 @@
+package summarizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		limit       int
+		want        string
+		wantDropped int
+	}{
+		{name: "short", text: "line one\nline two", limit: 20, want: "line one\nline two"},
+		{name: "at a line break", text: "line one\nline two\nline three", limit: 20, want: "line one\nline two", wantDropped: 11},
+		{name: "long line", text: "a\n" + strings.Repeat("b", 30), limit: 10, want: "a\nbbbbbbbb", wantDropped: 22},
+		{name: "multi-byte character", text: "ääääää", limit: 5, want: "ää", wantDropped: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped := truncateContext(tt.text, tt.limit)
+			if got != tt.want || dropped != tt.wantDropped {
+				t.Errorf("truncateContext() = %q, %d, want %q, %d", got, dropped, tt.want, tt.wantDropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package summarizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// responseSnippetLength is the maximum number of body bytes included in decode diagnostics.
+const responseSnippetLength = 200
+
+// strictJSON makes the response decoders reject bodies that don't match the expected envelope
+// instead of taking whatever text they can find.
+var strictJSON bool
+
+// decodeError describes a provider response that could not be turned into a result.
+type decodeError struct {
+	provider string
+	reason   string
+	body     []byte
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("unexpected %s response: %s (body: %s)", e.provider, e.reason, bodySnippet(e.body))
+}
+
+// bodySnippet returns the start of a response body, trimmed for use in error messages.
+func bodySnippet(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if s == "" {
+		return "<empty>"
+	}
+	if len(s) > responseSnippetLength {
+		return s[:runeBoundary(s, responseSnippetLength)] + "..."
+	}
+	return s
+}
+
+// unmarshalResponse decodes body into v. In strict mode trailing data after the JSON value is an error.
+func unmarshalResponse(provider string, body []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(v); err != nil {
+		return &decodeError{provider: provider, reason: err.Error(), body: body}
+	}
+	if strictJSON && dec.Decode(&struct{}{}) != io.EOF {
+		return &decodeError{provider: provider, reason: "trailing data after JSON value", body: body}
+	}
+	return nil
+}
+
+// decodeAnthropicResponse extracts the summary text from an Anthropic messages API response.
+func decodeAnthropicResponse(body []byte) (string, error) {
+	const provider = "Anthropic"
+
+	var result struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return "", err
+	}
+
+	// Errors normally come with a non-2xx status, but an error envelope is never a summary
+	if result.Type == "error" {
+		if err := parseAnthropicError(0, body); err != nil {
+			return "", err
+		}
+	}
+
+	if strictJSON && result.Type != "message" {
+		return "", &decodeError{provider: provider, reason: fmt.Sprintf("expected type \"message\", got %q", result.Type), body: body}
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type != "" && block.Type != "text" {
+			if strictJSON {
+				return "", &decodeError{provider: provider, reason: fmt.Sprintf("unexpected content block type %q", block.Type), body: body}
+			}
+			continue
+		}
+		text.WriteString(block.Text)
+	}
+
+	if text.Len() == 0 {
+		return "", &decodeError{provider: provider, reason: "no text content in response", body: body}
+	}
+	return text.String(), nil
+}
+
+// decodeAnthropicToolInput extracts the input of the call of tool from an Anthropic messages API
+// response, as JSON text.
+func decodeAnthropicToolInput(body []byte, tool string) (string, error) {
+	const provider = "Anthropic"
+
+	var result struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return "", err
+	}
+	if err := parseAnthropicError(0, body); err != nil {
+		return "", err
+	}
+	for _, block := range result.Content {
+		if block.Type == "tool_use" && block.Name == tool && len(block.Input) > 0 {
+			return string(block.Input), nil
+		}
+	}
+	return "", &decodeError{provider: provider, reason: fmt.Sprintf("no %s tool call in response", tool), body: body}
+}
+
+// decodeOllamaEmbeddingResponse extracts the embedding vector from an Ollama embeddings API response.
+func decodeOllamaEmbeddingResponse(body []byte) ([]float64, error) {
+	const provider = "Ollama"
+
+	var result OllamaEmbeddingResponse
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return nil, err
+	}
+	if strictJSON && len(result.Embedding) == 0 {
+		return nil, &decodeError{provider: provider, reason: "empty embedding", body: body}
+	}
+	return result.Embedding, nil
+}
+
+// decodeOllamaCompletionResponse extracts the generated text from an Ollama generate API response.
+func decodeOllamaCompletionResponse(body []byte) (string, error) {
+	const provider = "Ollama"
+
+	var result OllamaCompletionResponse
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return "", err
+	}
+	if strictJSON && !result.Done {
+		return "", &decodeError{provider: provider, reason: "completion is not done", body: body}
+	}
+	return result.Response, nil
+}
+
+// decodeOpenAIResponse extracts the summary text from an OpenAI chat completions API response.
+func decodeOpenAIResponse(body []byte) (string, error) {
+	const provider = "OpenAI"
+
+	var result struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return "", err
+	}
+
+	if strictJSON && result.Object != "chat.completion" {
+		return "", &decodeError{provider: provider, reason: fmt.Sprintf("expected object \"chat.completion\", got %q", result.Object), body: body}
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", &decodeError{provider: provider, reason: "no message content in response", body: body}
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// decodeGeminiResponse extracts the summary text from a Gemini generateContent API response.
+func decodeGeminiResponse(body []byte) (string, error) {
+	const provider = "Gemini"
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+			FinishReason string `json:"finishReason"`
+		} `json:"candidates"`
+	}
+	if err := unmarshalResponse(provider, body, &result); err != nil {
+		return "", err
+	}
+
+	if len(result.Candidates) == 0 {
+		return "", &decodeError{provider: provider, reason: "no candidates in response", body: body}
+	}
+	candidate := result.Candidates[0]
+	if strictJSON && candidate.FinishReason != "" && candidate.FinishReason != "STOP" {
+		return "", &decodeError{provider: provider, reason: fmt.Sprintf("generation finished with %q", candidate.FinishReason), body: body}
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		text.WriteString(part.Text)
+	}
+	if text.Len() == 0 {
+		return "", &decodeError{provider: provider, reason: "no text content in response", body: body}
+	}
+	return text.String(), nil
+}
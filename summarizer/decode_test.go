@@ -0,0 +1,171 @@
+package summarizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// withStrictJSON sets strictJSON for the duration of the test.
+func withStrictJSON(t *testing.T, strict bool) {
+	t.Helper()
+	original := strictJSON
+	strictJSON = strict
+	t.Cleanup(func() { strictJSON = original })
+}
+
+func TestUnmarshalResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		wantErr string
+	}{
+		{name: "object", body: `{"text":"ok"}`},
+		{name: "trailing whitespace", body: "{\"text\":\"ok\"}\n\n", strict: true},
+		{name: "trailing value ignored", body: `{"text":"ok"} {"text":"more"}`},
+		{name: "trailing value in strict mode", body: `{"text":"ok"} {"text":"more"}`, strict: true, wantErr: "trailing data after JSON value"},
+		{name: "trailing garbage in strict mode", body: `{"text":"ok"} garbage`, strict: true, wantErr: "trailing data after JSON value"},
+		{name: "trailing closing bracket in strict mode", body: `{"text":"ok"}]`, strict: true, wantErr: "trailing data after JSON value"},
+		{name: "not JSON", body: "<html>Bad Gateway</html>", wantErr: "invalid character"},
+		{name: "empty body", body: "", wantErr: "(body: <empty>)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStrictJSON(t, tt.strict)
+			var v struct {
+				Text string `json:"text"`
+			}
+			err := unmarshalResponse("Test", []byte(tt.body), &v)
+			if tt.wantErr == "" {
+				if err != nil || v.Text != "ok" {
+					t.Fatalf("unmarshalResponse() = %q, %v, want ok", v.Text, err)
+				}
+				return
+			}
+			var decodeErr *decodeError
+			if !errors.As(err, &decodeErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("unmarshalResponse() error = %v, want a decodeError containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeOpenAIResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		want    string
+		wantErr string
+	}{
+		{name: "chat completion", body: `{"object":"chat.completion","choices":[{"message":{"content":"Summary"}}]}`, strict: true, want: "Summary"},
+		{name: "missing object", body: `{"choices":[{"message":{"content":"Summary"}}]}`, want: "Summary"},
+		{name: "missing object in strict mode", body: `{"choices":[{"message":{"content":"Summary"}}]}`, strict: true, wantErr: `expected object "chat.completion", got ""`},
+		{name: "no choices", body: `{"object":"chat.completion","choices":[]}`, wantErr: "no message content in response"},
+		{name: "empty content", body: `{"choices":[{"message":{"content":""}}]}`, wantErr: "no message content in response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStrictJSON(t, tt.strict)
+			got, err := decodeOpenAIResponse([]byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeOpenAIResponse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("decodeOpenAIResponse() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGeminiResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		want    string
+		wantErr string
+	}{
+		{name: "parts joined", body: `{"candidates":[{"content":{"parts":[{"text":"Sum"},{"text":"mary"}]},"finishReason":"STOP"}]}`, strict: true, want: "Summary"},
+		{name: "max tokens", body: `{"candidates":[{"content":{"parts":[{"text":"Sum"}]},"finishReason":"MAX_TOKENS"}]}`, want: "Sum"},
+		{name: "max tokens in strict mode", body: `{"candidates":[{"content":{"parts":[{"text":"Sum"}]},"finishReason":"MAX_TOKENS"}]}`, strict: true, wantErr: `generation finished with "MAX_TOKENS"`},
+		{name: "no candidates", body: `{"candidates":[]}`, wantErr: "no candidates in response"},
+		{name: "no text", body: `{"candidates":[{"content":{"parts":[]},"finishReason":"SAFETY"}]}`, wantErr: "no text content in response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStrictJSON(t, tt.strict)
+			got, err := decodeGeminiResponse([]byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeGeminiResponse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("decodeGeminiResponse() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAnthropicResponseStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		want    string
+		wantErr string
+	}{
+		{name: "message", body: `{"type":"message","content":[{"type":"text","text":"Summary"}]}`, strict: true, want: "Summary"},
+		{name: "other blocks skipped", body: `{"type":"message","content":[{"type":"thinking","text":"hmm"},{"type":"text","text":"Summary"}]}`, want: "Summary"},
+		{name: "other blocks in strict mode", body: `{"type":"message","content":[{"type":"thinking","text":"hmm"},{"type":"text","text":"Summary"}]}`, strict: true, wantErr: `unexpected content block type "thinking"`},
+		{name: "missing type in strict mode", body: `{"content":[{"type":"text","text":"Summary"}]}`, strict: true, wantErr: `expected type "message", got ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStrictJSON(t, tt.strict)
+			got, err := decodeAnthropicResponse([]byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeAnthropicResponse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("decodeAnthropicResponse() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodySnippet(t *testing.T) {
+	long := strings.Repeat("a", responseSnippetLength-1) + "é and more"
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "short", body: "  {\"error\":\"bad\"}\n", want: `{"error":"bad"}`},
+		{name: "empty", body: " \n", want: "<empty>"},
+		{name: "cut before a split character", body: long, want: strings.Repeat("a", responseSnippetLength-1) + "..."},
+	}
+	for _, tt := range tests {
+		got := bodySnippet([]byte(tt.body))
+		if got != tt.want {
+			t.Errorf("bodySnippet(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("bodySnippet(%s) = %q, not valid UTF-8", tt.name, got)
+		}
+	}
+}
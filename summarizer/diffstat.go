@@ -0,0 +1,161 @@
+package summarizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileStat is the number of lines added and deleted in a single file.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// parseNumstat parses the output of git diff --numstat. Binary files, reported as "-" counts, are flagged,
+// and renamed files are listed under their new path.
+func parseNumstat(output string) ([]FileStat, error) {
+	stats := []FileStat{}
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected numstat line %q", line)
+		}
+		stat := FileStat{Path: renamedPath(fields[2])}
+		if fields[0] == "-" && fields[1] == "-" {
+			stat.Binary = true
+		} else {
+			var err error
+			if stat.Added, err = strconv.Atoi(fields[0]); err != nil {
+				return nil, fmt.Errorf("unexpected numstat line %q", line)
+			}
+			if stat.Deleted, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, fmt.Errorf("unexpected numstat line %q", line)
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// diffFileStats derives per-file statistics from the file headers and hunks of a unified diff.
+// It is used when the diff doesn't come from git, e.g. when it is read from stdin.
+func diffFileStats(diff string) []FileStat {
+	stats := []FileStat{}
+	for _, chunk := range splitDiffByFile(diff) {
+		if !strings.HasPrefix(chunk, "diff --git ") {
+			continue
+		}
+		if _, _, binary := cutBinaryContent(chunk); binary {
+			stats = append(stats, FileStat{Path: diffFilePath(chunk), Binary: true})
+			continue
+		}
+		added, removed := countChangedLines(chunk)
+		stats = append(stats, FileStat{Path: diffFilePath(chunk), Added: added, Deleted: removed})
+	}
+	return stats
+}
+
+// diffOverview formats file statistics like git diff --stat, for diffs that don't come from git.
+func diffOverview(stats []FileStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	var overview strings.Builder
+	var insertions, deletions int
+	for _, stat := range stats {
+		if stat.Binary {
+			fmt.Fprintf(&overview, " %s | Bin\n", stat.Path)
+			continue
+		}
+		fmt.Fprintf(&overview, " %s | %d +%d -%d\n", stat.Path, stat.Added+stat.Deleted, stat.Added, stat.Deleted)
+		insertions += stat.Added
+		deletions += stat.Deleted
+	}
+	fmt.Fprintf(&overview, " %d files changed, %d insertions(+), %d deletions(-)", len(stats), insertions, deletions)
+	return overview.String()
+}
+
+// topFileStats formats the files with the most changed lines as a short list for the prompt.
+func topFileStats(stats []FileStat, limit int) string {
+	sorted := append([]FileStat(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Added+sorted[i].Deleted > sorted[j].Added+sorted[j].Deleted
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	var lines []string
+	for _, stat := range sorted {
+		if stat.Binary {
+			lines = append(lines, fmt.Sprintf("- %s (binary)", stat.Path))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s (+%d -%d)", stat.Path, stat.Added, stat.Deleted))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countChangedLines counts the added and removed lines in the hunks of a single-file diff chunk.
+// It stops after the lines the last hunk header announces, or at the first line outside a hunk,
+// so the "-- " signature and the next commit of git format-patch output aren't counted.
+func countChangedLines(chunk string) (added, removed int) {
+	inHunk, counted := false, false
+	var oldLeft, newLeft int
+	for _, line := range strings.Split(chunk, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			oldLeft, newLeft, counted = hunkLineCounts(line)
+			inHunk = true
+		case !inHunk:
+			// Skip the file header, including its ---/+++ lines
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file"
+		case counted && oldLeft <= 0 && newLeft <= 0,
+			!isHunkLine(line) && (line != "" || !counted):
+			return added, removed
+		case strings.HasPrefix(line, "+"):
+			added++
+			newLeft--
+		case strings.HasPrefix(line, "-"):
+			removed++
+			oldLeft--
+		default:
+			// A context line, or a blank one whose leading space was stripped
+			oldLeft--
+			newLeft--
+		}
+	}
+	return added, removed
+}
+
+// hunkLineCounts returns the number of old and new lines a "@@ -1,3 +1,4 @@" hunk header announces.
+func hunkLineCounts(header string) (oldLines, newLines int, ok bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, false
+	}
+	oldLines, okOld := rangeLength(fields[1][1:])
+	newLines, okNew := rangeLength(fields[2][1:])
+	if !okOld || !okNew {
+		return 0, 0, false
+	}
+	return oldLines, newLines, true
+}
+
+// rangeLength returns the length of a "start,length" hunk range, which is 1 when it is omitted.
+func rangeLength(r string) (int, bool) {
+	_, length, found := strings.Cut(r, ",")
+	if !found {
+		return 1, true
+	}
+	n, err := strconv.Atoi(length)
+	return n, err == nil
+}
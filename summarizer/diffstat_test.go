@@ -0,0 +1,192 @@
+package summarizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// formatPatch is git format-patch output of two commits, each followed by the "-- " signature.
+const formatPatch = `From 1234567890abcdef1234567890abcdef12345678 Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Date: Mon, 1 Jan 2024 00:00:00 +0000
+Subject: [PATCH 1/2] Add parser
+ for the config format
+
+---
+ parser.go | 3 ++-
+ 1 file changed, 2 insertions(+), 1 deletion(-)
+
+diff --git a/parser.go b/parser.go
+index 1111111..2222222 100644
+--- a/parser.go
++++ b/parser.go
+@@ -1,2 +1,3 @@
+ package parser
+-func Parse() {}
++func Parse() error {
++}
+-- 
+2.40.0
+
+
+From abcdef1234567890abcdef1234567890abcdef12 Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Date: Mon, 1 Jan 2024 00:00:00 +0000
+Subject: [PATCH 2/2] Fix lexer
+
+---
+ lexer.go | 1 +
+ 1 file changed, 1 insertion(+)
+
+diff --git a/lexer.go b/lexer.go
+index 3333333..4444444 100644
+--- a/lexer.go
++++ b/lexer.go
+@@ -1 +1,2 @@
+ package lexer
++
+\ No newline at end of file
+-- 
+2.40.0
+`
+
+func TestParseNumstat(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []FileStat
+		wantErr bool
+	}{
+		{name: "empty", output: "", want: []FileStat{}},
+		{
+			name:   "text files",
+			output: "10\t2\tmain.go\n0\t5\tdocs/old.md\n",
+			want:   []FileStat{{Path: "main.go", Added: 10, Deleted: 2}, {Path: "docs/old.md", Deleted: 5}},
+		},
+		{
+			name:   "binary file",
+			output: "-\t-\tlogo.png",
+			want:   []FileStat{{Path: "logo.png", Binary: true}},
+		},
+		{
+			name:   "renames",
+			output: "1\t1\told.go => new.go\n3\t0\tsrc/{util => lib}/strings.go\n0\t0\t{ => pkg}/a.go",
+			want: []FileStat{
+				{Path: "new.go", Added: 1, Deleted: 1},
+				{Path: "src/lib/strings.go", Added: 3},
+				{Path: "pkg/a.go"},
+			},
+		},
+		{name: "path with a tab", output: "1\t0\tweird\tname.txt", want: []FileStat{{Path: "weird\tname.txt", Added: 1}}},
+		{name: "missing path", output: "1\t2", wantErr: true},
+		{name: "non-numeric count", output: "x\t2\tmain.go", wantErr: true},
+		{name: "half binary", output: "-\t2\tmain.go", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNumstat(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumstat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNumstat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOverview(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats []FileStat
+		want  string
+	}{
+		{name: "no files", want: ""},
+		{
+			name:  "text and binary files",
+			stats: []FileStat{{Path: "main.go", Added: 10, Deleted: 2}, {Path: "logo.png", Binary: true}, {Path: "new.go", Added: 3}},
+			want:  " main.go | 12 +10 -2\n logo.png | Bin\n new.go | 3 +3 -0\n 3 files changed, 13 insertions(+), 2 deletions(-)",
+		},
+		{
+			name:  "renamed file without changes",
+			stats: []FileStat{{Path: "pkg/a.go"}},
+			want:  " pkg/a.go | 0 +0 -0\n 1 files changed, 0 insertions(+), 0 deletions(-)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffOverview(tt.stats); got != tt.want {
+				t.Errorf("diffOverview() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFileStats(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []FileStat
+	}{
+		{
+			name: "plain diff",
+			diff: "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-a\n+b\n c\n",
+			want: []FileStat{{Path: "a.go", Added: 1, Deleted: 1}},
+		},
+		{
+			name: "several hunks",
+			diff: "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n@@ -10,0 +11,2 @@\n+c\n+d\n",
+			want: []FileStat{{Path: "a.go", Added: 3, Deleted: 1}},
+		},
+		{
+			name: "blank context line without its space",
+			diff: "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n-a\n+b\n\n-c\n+d\n",
+			want: []FileStat{{Path: "a.go", Added: 2, Deleted: 2}},
+		},
+		{
+			name: "format-patch signature and next commit",
+			diff: formatPatch,
+			want: []FileStat{{Path: "parser.go", Added: 2, Deleted: 1}, {Path: "lexer.go", Added: 1}},
+		},
+		{
+			name: "binary file",
+			diff: "diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n",
+			want: []FileStat{{Path: "logo.png", Binary: true}},
+		},
+		{
+			name: "no git headers",
+			diff: "--- a.go\n+++ a.go\n@@ -1 +1 @@\n-a\n+b\n",
+			want: []FileStat{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffFileStats(tt.diff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffFileStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHunkLineCounts(t *testing.T) {
+	tests := []struct {
+		header             string
+		oldLines, newLines int
+		ok                 bool
+	}{
+		{header: "@@ -1,3 +1,4 @@", oldLines: 3, newLines: 4, ok: true},
+		{header: "@@ -1 +1 @@ func main() {", oldLines: 1, newLines: 1, ok: true},
+		{header: "@@ -0,0 +1,2 @@", oldLines: 0, newLines: 2, ok: true},
+		{header: "@@ -1,x +1 @@", ok: false},
+		{header: "@@", ok: false},
+	}
+	for _, tt := range tests {
+		oldLines, newLines, ok := hunkLineCounts(tt.header)
+		if oldLines != tt.oldLines || newLines != tt.newLines || ok != tt.ok {
+			t.Errorf("hunkLineCounts(%q) = %d, %d, %v, want %d, %d, %v", tt.header, oldLines, newLines, ok, tt.oldLines, tt.newLines, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskCacheVersion is stored with every entry and must be bumped whenever the cached data
+// or the way it is produced changes, so old entries are ignored.
+const diskCacheVersion = 1
+
+// diskCacheTTL is how long a cached compression stays valid.
+var diskCacheTTL = 7 * 24 * time.Hour
+
+// noDiskCache disables reading and writing the on-disk cache, but not the compressions kept to resume from.
+var noDiskCache bool
+
+// resumeTTL is how long the compression of a run whose summary failed can be resumed from.
+var resumeTTL = time.Hour
+
+// diskCacheEntry is the on-disk representation of a cached compression.
+type diskCacheEntry struct {
+	Version       int         `json:"version"`
+	CompressModel string      `json:"compressModel"`
+	EmbedModel    string      `json:"embedModel"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	Compression   compression `json:"compression"`
+}
+
+// diskCacheDir returns the directory holding cache entries, $XDG_CACHE_HOME/prgpt by default.
+func diskCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "prgpt"), nil
+}
+
+// cacheKey returns the key of the cache entry for the given content, the current models and embeddings source.
+func cacheKey(content string) string {
+	keyText := ollamaCompletionModel + "\x00" + ollamaEmbeddingModel + "\x00" + content
+	if embedSource != "compressed" {
+		// Keep the keys of existing entries for the default source
+		keyText = embedSource + "\x00" + keyText
+	}
+	return hashText(keyText)
+}
+
+// diskCachePath returns the cache file for the given content.
+func diskCachePath(content string) (string, error) {
+	dir, err := diskCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheKey(content)+".json"), nil
+}
+
+// resumePath returns the file the compression of content is kept in until its summary succeeded.
+// It lives in the resume directory of the cache, apart from the entries shared between runs, and
+// is used with -no-cache too.
+func resumePath(content string) (string, error) {
+	dir, err := diskCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "resume", cacheKey(content)+".json"), nil
+}
+
+// loadCachedCompression returns the cached compression for content if a fresh entry
+// produced by the current models and cache version exists.
+func loadCachedCompression(content string) (compression, bool) {
+	if noDiskCache {
+		return compression{}, false
+	}
+
+	path, err := diskCachePath(content)
+	if err != nil {
+		return compression{}, false
+	}
+	return readCacheEntry(path, diskCacheTTL)
+}
+
+// loadResumedCompression returns the compression a previous run stored for content if its summary
+// failed less than resumeTTL ago.
+func loadResumedCompression(content string) (compression, bool) {
+	path, err := resumePath(content)
+	if err != nil {
+		return compression{}, false
+	}
+	return readCacheEntry(path, resumeTTL)
+}
+
+// readCacheEntry reads the cache entry at path if it is younger than ttl and was produced by the
+// current models and cache version.
+func readCacheEntry(path string, ttl time.Duration) (compression, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return compression{}, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logf("Ignoring unreadable cache entry %s: %v", path, err)
+		return compression{}, false
+	}
+	if entry.Version != diskCacheVersion || entry.CompressModel != ollamaCompletionModel ||
+		entry.EmbedModel != ollamaEmbeddingModel || time.Since(entry.CreatedAt) > ttl {
+		logf("Ignoring stale cache entry %s", path)
+		return compression{}, false
+	}
+	return entry.Compression, true
+}
+
+// storeCachedCompression writes the compression for content to the cache. Failures are only logged.
+func storeCachedCompression(content string, c compression) {
+	if noDiskCache {
+		return
+	}
+
+	path, err := diskCachePath(content)
+	if err == nil {
+		err = writeCacheEntry(path, c)
+	}
+	if err != nil {
+		logf("Could not write cache entry: %v", err)
+	}
+}
+
+// storeResumableCompression keeps the compression for content until clearResumableCompression is
+// called after its summary succeeded. Failures are only logged.
+func storeResumableCompression(content string, c compression) {
+	path, err := resumePath(content)
+	if err == nil {
+		err = writeCacheEntry(path, c)
+	}
+	if err != nil {
+		logf("Could not keep the compression to resume from: %v", err)
+	}
+}
+
+// clearResumableCompression removes the compression kept for content, once it isn't needed anymore.
+func clearResumableCompression(content string) {
+	path, err := resumePath(content)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logf("Could not remove %s: %v", path, err)
+	}
+}
+
+// writeCacheEntry writes a cache entry to path atomically via a temporary file. The directories
+// are only accessible to the user, since the entries hold summaries of private code.
+func writeCacheEntry(path string, c compression) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diskCacheEntry{
+		Version:       diskCacheVersion,
+		CompressModel: ollamaCompletionModel,
+		EmbedModel:    ollamaEmbeddingModel,
+		CreatedAt:     time.Now(),
+		Compression:   c,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// clearDiskCache removes all cache entries and the compressions kept to resume from.
+func clearDiskCache() error {
+	dir, err := diskCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("error removing %s: %v", dir, err)
+	}
+	return nil
+}
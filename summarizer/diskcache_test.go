@@ -0,0 +1,54 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResumableCompression(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	content := "Detailed Changes:\ndiff\n\nChanges Overview:\n a.go | 1 +"
+	want := compression{Compressed: "compressed", Embeddings: []float64{0.5}}
+	storeResumableCompression(content, want)
+
+	got, ok := loadResumedCompression(content)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("loadResumedCompression() = %+v, %v, want %+v, true", got, ok, want)
+	}
+	if _, ok := loadResumedCompression(content + "\n b.go | 1 +"); ok {
+		t.Errorf("loadResumedCompression() found an entry for other content")
+	}
+
+	info, err := os.Stat(filepath.Join(cacheHome, "prgpt", "resume"))
+	if err != nil {
+		t.Fatalf("resume directory: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("resume directory mode = %v, want 0700", perm)
+	}
+
+	clearResumableCompression(content)
+	if _, ok := loadResumedCompression(content); ok {
+		t.Errorf("loadResumedCompression() found an entry after clearResumableCompression")
+	}
+}
+
+func TestResumableCompressionNoCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	noDiskCache = true
+	t.Cleanup(func() { noDiskCache = false })
+
+	content := "Detailed Changes:\ndiff"
+	want := compression{Compressed: "compressed"}
+	storeResumableCompression(content, want)
+	if got, ok := loadResumedCompression(content); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("loadResumedCompression() with -no-cache = %+v, %v, want %+v, true", got, ok, want)
+	}
+	if _, ok := loadCachedCompression(content); ok {
+		t.Errorf("loadCachedCompression() with -no-cache found an entry")
+	}
+}
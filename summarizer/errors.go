@@ -0,0 +1,15 @@
+package summarizer
+
+import "errors"
+
+// Errors the summary pipeline returns, wrapped in more detailed errors. Test for them with errors.Is.
+var (
+	// ErrProviderAuth means the summary provider rejected the API key or credentials.
+	ErrProviderAuth = errors.New("the summary provider rejected the credentials")
+	// ErrProviderRateLimited means the summary provider kept rate limiting the requests.
+	ErrProviderRateLimited = errors.New("the summary provider rate limited the request")
+	// ErrOllamaUnavailable means the Ollama server could not be reached.
+	ErrOllamaUnavailable = errors.New("Ollama is unavailable")
+	// ErrEmptyDiff means there are no file changes to summarize.
+	ErrEmptyDiff = errors.New("no file changes to summarize")
+)
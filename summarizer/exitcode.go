@@ -0,0 +1,50 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes reported by prgpt.
+const (
+	exitOK        = 0
+	exitFailure   = 1 // git errors and other failures
+	exitConfig    = 2 // invalid flags, configuration or missing credentials
+	exitAPI       = 3 // summary provider or Ollama API failures
+	exitCancelled = 130
+)
+
+// exitError is an error that ends the run with a specific exit code.
+// Its message is printed to stderr as is.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// fail returns an exitError with the given code and formatted message.
+func fail(code int, format string, args ...interface{}) error {
+	return &exitError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// errCancelled is returned once the run was cancelled by a signal.
+var errCancelled = &exitError{code: exitCancelled, err: errors.New("cancelled")}
+
+// exitCode returns the exit code for the error returned by run.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,78 @@
+package summarizer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// remoteBranchOf returns the remote and the branch on it that base stands for, along with the
+// remote-tracking ref: base itself for a ref such as origin/main, the upstream for a local branch.
+// It reports false for refs without a remote, such as tags, commits and local-only branches.
+func remoteBranchOf(base string) (remote, branch, tracking string, ok bool) {
+	tracking = base
+	if upstream, err := getCommandOutput("git", "rev-parse", "--abbrev-ref", "--verify", "--quiet", base+"@{upstream}"); err == nil && upstream != "" {
+		tracking = upstream
+	}
+	remotes, err := getCommandOutput("git", "remote")
+	if err != nil {
+		return "", "", "", false
+	}
+	for _, name := range strings.Fields(remotes) {
+		if branch, ok := strings.CutPrefix(tracking, name+"/"); ok && branch != "" {
+			return name, branch, tracking, true
+		}
+	}
+	return "", "", "", false
+}
+
+// countCommits returns the number of commits in the from..to range.
+func countCommits(from, to string) (int, error) {
+	output, err := getCommandOutput("git", "rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(output)
+}
+
+// refreshBase returns the ref to compare against for the base branch. With fetch, it fetches the
+// branch of base from its remote first and switches to the remote-tracking ref when the local
+// branch is behind it; a failed fetch, e.g. without network, keeps the local ref. Without fetch it
+// only warns when the local branch is behind the remote-tracking ref it was last fetched into.
+func refreshBase(base string, fetch bool) string {
+	remote, branch, tracking, ok := remoteBranchOf(base)
+	if !ok {
+		if fetch {
+			warnf("Warning: %s has no remote branch to fetch, comparing against the local ref", base)
+		}
+		return base
+	}
+
+	if fetch {
+		status.set("Fetching " + tracking)
+		_, err := getCommandOutput("git", "fetch", "--quiet", remote, branch)
+		status.clear()
+		if err != nil {
+			warnf("Warning: could not fetch %s from %s, comparing against the local %s: %v", branch, remote, base, err)
+			return base
+		}
+		logf("Fetched %s from %s", branch, remote)
+	}
+	if tracking == base {
+		return base
+	}
+
+	behind, err := countCommits(base, tracking)
+	if err != nil || behind == 0 {
+		return base
+	}
+	if !fetch {
+		warnf("Warning: %s is %s behind %s, pass -fetch to compare against the latest remote state", base, countNoun(behind, "commit"), tracking)
+		return base
+	}
+	if ahead, err := countCommits(tracking, base); err != nil || ahead > 0 {
+		warnf("Warning: %s has diverged from %s, comparing against the local %s", base, tracking, base)
+		return base
+	}
+	logf("Using %s as the base branch, %s is %s behind it", tracking, base, countNoun(behind, "commit"))
+	return tracking
+}
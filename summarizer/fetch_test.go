@@ -0,0 +1,76 @@
+package summarizer
+
+import "testing"
+
+func TestRefreshBase(t *testing.T) {
+	const (
+		upstream = "git rev-parse --abbrev-ref --verify --quiet main@{upstream}"
+		behind   = "git rev-list --count main..origin/main"
+		ahead    = "git rev-list --count origin/main..main"
+		fetch    = "git fetch --quiet origin main"
+	)
+	tests := []struct {
+		name     string
+		base     string
+		fetch    bool
+		commands map[string]string
+		want     string
+	}{
+		{
+			name:     "up to date",
+			base:     "main",
+			commands: map[string]string{upstream: "origin/main", "git remote": "origin", behind: "0"},
+			want:     "main",
+		},
+		{
+			name:     "behind without fetch only warns",
+			base:     "main",
+			commands: map[string]string{upstream: "origin/main", "git remote": "origin", behind: "2"},
+			want:     "main",
+		},
+		{
+			name:     "behind after fetch",
+			base:     "main",
+			fetch:    true,
+			commands: map[string]string{upstream: "origin/main", "git remote": "origin", fetch: "", behind: "2", ahead: "0"},
+			want:     "origin/main",
+		},
+		{
+			name:     "diverged after fetch",
+			base:     "main",
+			fetch:    true,
+			commands: map[string]string{upstream: "origin/main", "git remote": "origin", fetch: "", behind: "2", ahead: "1"},
+			want:     "main",
+		},
+		{
+			name:     "failed fetch keeps the local ref",
+			base:     "main",
+			fetch:    true,
+			commands: map[string]string{upstream: "origin/main", "git remote": "origin", behind: "2", ahead: "0"},
+			want:     "main",
+		},
+		{
+			name:     "remote-tracking base",
+			base:     "origin/main",
+			fetch:    true,
+			commands: map[string]string{"git remote": "upstream\norigin", fetch: ""},
+			want:     "origin/main",
+		},
+		{
+			name:     "local-only branch",
+			base:     "topic",
+			fetch:    true,
+			commands: map[string]string{"git remote": "origin"},
+			want:     "topic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCommands(t, tt.commands)
+			if got := refreshBase(tt.base, tt.fetch); got != tt.want {
+				t.Fatalf("refreshBase(%q, %v) = %q, want %q", tt.base, tt.fetch, got, tt.want)
+			}
+		})
+	}
+}
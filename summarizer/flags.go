@@ -0,0 +1,44 @@
+package summarizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// stringListFlag is a flag.Value collecting every occurrence of a repeatable flag.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// keyValueFlag is a flag.Value collecting the key=value pairs of a repeatable flag.
+// A later pair with the same key replaces the earlier one.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("%q is not a key=value pair", value)
+	}
+	if key = strings.TrimSpace(key); key == "" {
+		return fmt.Errorf("%q has an empty key", value)
+	}
+	f[key] = val
+	return nil
+}
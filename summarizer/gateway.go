@@ -0,0 +1,116 @@
+package summarizer
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// headerFlag is a flag.Value collecting the "Name: Value" headers of the repeatable -header flag.
+// Its String method masks the values, so the headers never show up in -print-config or logs.
+type headerFlag []customHeader
+
+// customHeader is a header added to every summary provider request.
+type customHeader struct {
+	name, value string
+}
+
+func (f *headerFlag) String() string {
+	names := make([]string, len(*f))
+	for i, h := range *f {
+		names[i] = h.name + ": " + maskSecret(h.value)
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *headerFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("%q is not a \"Name: Value\" header", value)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("%q has an invalid header name", value)
+	}
+	*f = append(*f, customHeader{name: textproto.CanonicalMIMEHeaderKey(name), value: strings.TrimSpace(val)})
+	return nil
+}
+
+// customHeaders are the headers set with -header, e.g. the token of an LLM gateway.
+var customHeaders headerFlag
+
+// setCustomHeaders adds the -header headers to a summary provider request. They are set after the
+// provider's own headers, so a gateway can be given a different Authorization header. Repeating a
+// name sends the header with every value.
+func setCustomHeaders(req *http.Request) {
+	set := map[string]bool{}
+	for _, h := range customHeaders {
+		if !set[h.name] {
+			req.Header.Del(h.name)
+			set[h.name] = true
+		}
+		req.Header.Add(h.name, h.value)
+	}
+}
+
+// logCustomHeaders lists the names of the -header headers in verbose mode; their values are never logged.
+func logCustomHeaders() {
+	if len(customHeaders) == 0 {
+		return
+	}
+	names := map[string]bool{}
+	for _, h := range customHeaders {
+		names[h.name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	logf("Sending custom headers: %s", strings.Join(sorted, ", "))
+}
+
+// apiBase replaces the base URL of the summary provider when set with -api-base.
+var apiBase string
+
+// apiBasePaths are the paths the providers append to their base URL, following the conventions of
+// the official SDKs: an Anthropic base URL has no version, an OpenAI one ends in /v1.
+var apiBasePaths = map[string]string{
+	"anthropic": "/v1/messages",
+	"openai":    "/chat/completions",
+	"gemini":    "/models",
+	"bedrock":   "",
+}
+
+// setAPIBase points the API URL of provider at base, e.g. an internal gateway speaking the
+// provider's protocol.
+func setAPIBase(provider, base string) error {
+	path, ok := apiBasePaths[provider]
+	if !ok {
+		if provider == "azure" {
+			return fmt.Errorf("not supported with provider azure, set AZURE_OPENAI_ENDPOINT instead")
+		}
+		if provider == "ollama" {
+			return fmt.Errorf("not supported with provider ollama, set -ollama-url instead")
+		}
+		return fmt.Errorf("not supported with provider %s", provider)
+	}
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		return fmt.Errorf("%q must start with http:// or https://", base)
+	}
+	url := strings.TrimSuffix(base, "/") + path
+	switch provider {
+	case "anthropic":
+		anthropicAPIURL = url
+	case "openai":
+		openAIAPIURL = url
+	case "gemini":
+		geminiAPIURL = url
+	case "bedrock":
+		bedrockAPIURL = url
+	}
+	logf("Using API base URL %s", url)
+	return nil
+}
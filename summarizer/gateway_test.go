@@ -0,0 +1,88 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderFlag(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    customHeader
+		wantErr bool
+	}{
+		{value: "X-Gateway-Token: secret-token-1234", want: customHeader{name: "X-Gateway-Token", value: "secret-token-1234"}},
+		{value: "x-team:platform", want: customHeader{name: "X-Team", value: "platform"}},
+		{value: "no separator", wantErr: true},
+		{value: ": value", wantErr: true},
+		{value: "Bad Name: value", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var f headerFlag
+			err := f.Set(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Set(%q) succeeded, want an error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set(%q) error = %v", tt.value, err)
+			}
+			if f[0] != tt.want {
+				t.Errorf("Set(%q) = %+v, want %+v", tt.value, f[0], tt.want)
+			}
+		})
+	}
+
+	f := headerFlag{{name: "X-Gateway-Token", value: "secret-token-1234"}}
+	if got, want := f.String(), "X-Gateway-Token: ****1234"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestProviderCustomHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"message","content":[{"type":"text","text":"a summary"}]}`))
+	}))
+	defer server.Close()
+
+	originalURL, originalHeaders := anthropicAPIURL, customHeaders
+	defer func() { anthropicAPIURL, customHeaders = originalURL, originalHeaders }()
+	if err := setAPIBase("anthropic", server.URL+"/"); err != nil {
+		t.Fatalf("setAPIBase() error = %v", err)
+	}
+	if want := server.URL + "/v1/messages"; anthropicAPIURL != want {
+		t.Errorf("anthropicAPIURL = %q, want %q", anthropicAPIURL, want)
+	}
+	customHeaders = headerFlag{{name: "X-Gateway-Token", value: "abc"}, {name: "X-Api-Key", value: "gateway-key"}}
+
+	provider := &anthropicProvider{apiKey: "test-key", model: anthropicModel, maxTokens: anthropicMaxTokens}
+	if _, err := provider.Summarize(context.Background(), "prompt"); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if got := received.Get("X-Gateway-Token"); got != "abc" {
+		t.Errorf("X-Gateway-Token = %q, want %q", got, "abc")
+	}
+	if got := received.Values("X-Api-Key"); len(got) != 1 || got[0] != "gateway-key" {
+		t.Errorf("X-Api-Key = %q, want the custom header to replace the provider's", got)
+	}
+}
+
+func TestSetAPIBaseErrors(t *testing.T) {
+	for _, tt := range []struct{ provider, base string }{
+		{"azure", "https://gateway.internal"},
+		{"mock", "https://gateway.internal"},
+		{"openai", "gateway.internal"},
+	} {
+		if err := setAPIBase(tt.provider, tt.base); err == nil {
+			t.Errorf("setAPIBase(%q, %q) succeeded, want an error", tt.provider, tt.base)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// geminiProvider generates summaries with the Gemini generateContent API.
+type geminiProvider struct {
+	apiKey string
+	model  string
+}
+
+// Summarize sends the prompt to the Gemini generateContent API and returns the generated text.
+func (p *geminiProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	request := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"role":  "user",
+				"parts": []map[string]string{{"text": prompt}},
+			},
+		},
+	}
+	if systemPrompt != "" {
+		request["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]string{{"text": systemPrompt}},
+		}
+	}
+	generationConfig := map[string]interface{}{}
+	addSamplingParams(generationConfig, "temperature", "topP")
+	if len(generationConfig) > 0 {
+		request["generationConfig"] = generationConfig
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s:generateContent", geminiAPIURL, url.PathEscape(p.model))
+	_, body, err := doWithRetry(ctx, "Gemini API", func() (*http.Request, error) {
+		req, err := newJSONRequest(ctx, endpoint, requestBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("x-goog-api-key", p.apiKey)
+		setCustomHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	recordResponseUsage(body)
+	return decodeGeminiResponse(body)
+}
@@ -0,0 +1,37 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGeminiSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"candidates":[{"content":{"parts":[{"text":"a "},{"text":"summary"}]},"finishReason":"STOP"}]}`, want: "a summary"},
+		{name: "non-2xx status", status: http.StatusForbidden, body: `{"error":{"code":403,"message":"API key not valid"}}`, wantErr: true},
+		{name: "no candidates", status: http.StatusOK, body: `{"candidates":[]}`, wantErr: true},
+		{name: "malformed JSON", status: http.StatusOK, body: `{"candidates":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &geminiAPIURL, tt.status, tt.body)
+
+			provider := &geminiProvider{apiKey: "test-key", model: geminiModel}
+			got, err := provider.Summarize(context.Background(), "prompt")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Summarize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CommandRunner runs an external command and returns its trimmed output. Tests swap commandRunner
+// for a fake returning canned outputs, so the git logic can be exercised without a repository.
+type CommandRunner interface {
+	Run(name string, args ...string) (string, error)
+}
+
+// execRunner is the CommandRunner running real commands with os/exec, in dir if it is set.
+type execRunner struct {
+	dir string
+}
+
+// Run executes a command and returns its trimmed output as a string.
+// A missing executable and a failing command are reported as distinct errors, the latter including the command's stderr.
+func (r execRunner) Run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = r.dir
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s is not installed or not in PATH", name)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("error executing %s: %v", name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// commandRunner runs the git commands of prgpt.
+var commandRunner CommandRunner = execRunner{}
+
+// getCommandOutput executes a command with commandRunner and returns its trimmed output,
+// sanitized to valid UTF-8 without control characters.
+func getCommandOutput(name string, args ...string) (string, error) {
+	defer metrics.track(phaseGit)()
+	output, err := commandRunner.Run(name, args...)
+	return sanitizeText(output), err
+}
+
+// insideWorkTree reports whether the current directory is inside a git working tree.
+func insideWorkTree() bool {
+	output, err := getCommandOutput("git", "rev-parse", "--is-inside-work-tree")
+	return err == nil && output == "true"
+}
+
+// verifyRef checks that ref resolves to a commit using git rev-parse --verify.
+func verifyRef(ref string) error {
+	if _, err := getCommandOutput("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return fmt.Errorf("%q is not a valid git ref", ref)
+	}
+	return nil
+}
+
+// defaultBaseCandidates are the refs tried in order when no base branch is given.
+var defaultBaseCandidates = []string{"origin/HEAD", "main", "master", "develop"}
+
+// baseCandidates overrides defaultBaseCandidates when set with -base-candidate or the config file.
+var baseCandidates stringListFlag
+
+// resolveBaseBranch returns the base branch to compare against: the -base flag, the positional
+// argument kept for backwards compatibility, or the first base candidate that exists.
+func resolveBaseBranch(baseFlag string) (string, error) {
+	if baseFlag != "" {
+		return baseFlag, nil
+	}
+	if cmdFlags.NArg() > 0 {
+		return cmdFlags.Arg(0), nil
+	}
+
+	candidates := []string(baseCandidates)
+	if len(candidates) == 0 {
+		candidates = defaultBaseCandidates
+	}
+	for _, candidate := range candidates {
+		if candidate == "origin/HEAD" {
+			// origin/HEAD is missing in local-only repositories and isn't always set after a clone
+			if branch, ok := originHeadBranch(); ok {
+				return branch, nil
+			}
+			continue
+		}
+		if verifyRef(candidate) == nil {
+			logf("Using %s as the base branch", candidate)
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("none of %s exists, pass the base branch with -base <branch> "+
+		"(or run 'git remote set-head origin --auto')", strings.Join(candidates, ", "))
+}
+
+// originHeadBranch returns the branch origin/HEAD points at, preferring the local branch of the
+// same name and falling back to the remote-tracking ref when there is no local copy.
+func originHeadBranch() (string, bool) {
+	originHead, err := getCommandOutput("git", "rev-parse", "--abbrev-ref", "--verify", "--quiet", "origin/HEAD")
+	if err != nil || originHead == "" {
+		return "", false
+	}
+	if local := strings.TrimPrefix(originHead, "origin/"); verifyRef(local) == nil {
+		logf("Using %s (origin/HEAD) as the base branch", local)
+		return local, true
+	}
+	logf("Using %s (origin/HEAD) as the base branch", originHead)
+	return originHead, true
+}
+
+// diffAlgorithms lists the values git diff accepts for --diff-algorithm.
+var diffAlgorithms = []string{"myers", "minimal", "patience", "histogram"}
+
+// defaultContextLines is the number of context lines git diff shows around each change by default.
+const defaultContextLines = 3
+
+// diffOptions returns the git diff options selecting the diff algorithm, word-level diffs and the
+// number of context lines. An empty algorithm keeps git's default (myers).
+func diffOptions(algorithm string, wordDiff bool, contextLines int) ([]string, error) {
+	var options []string
+	if contextLines < 0 {
+		return nil, fmt.Errorf("-context must not be negative")
+	}
+	if contextLines != defaultContextLines {
+		options = append(options, fmt.Sprintf("--unified=%d", contextLines))
+	}
+	if algorithm != "" {
+		if !slices.Contains(diffAlgorithms, algorithm) {
+			return nil, fmt.Errorf("-diff-algorithm: unknown diff algorithm %q (expected %s)", algorithm, strings.Join(diffAlgorithms, ", "))
+		}
+		options = append(options, "--diff-algorithm="+algorithm)
+	}
+	if wordDiff {
+		options = append(options, "--word-diff=plain")
+	}
+	return options, nil
+}
+
+// emptyTreeHash is the hash of git's empty tree, used to diff from before the root commit.
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// sinceRange returns the diff base for the commits on head since the git date spec, or ""
+// when there are none. It is the parent of the oldest such commit, or the empty tree for a root commit.
+func sinceRange(since, head string) (string, error) {
+	hashes, err := getCommandOutput("git", "log", "--since="+since, "--reverse", "--pretty=format:%H", head)
+	if err != nil || hashes == "" {
+		return "", err
+	}
+	oldest, _, _ := strings.Cut(hashes, "\n")
+	if err := verifyRef(oldest + "^"); err != nil {
+		return emptyTreeHash, nil
+	}
+	return oldest + "^", nil
+}
+
+// pathspecArgs returns the "--" separated pathspec arguments limiting git diff and git log to the
+// include paths, minus the paths matching the exclude globs. It returns nil when both are empty.
+func pathspecArgs(includes, excludes []string) []string {
+	if len(includes) == 0 && len(excludes) == 0 {
+		return nil
+	}
+	args := []string{"--"}
+	for _, path := range includes {
+		args = append(args, ":(top)"+path)
+	}
+	return append(args, excludePathspecs(excludes)...)
+}
+
+// excludePathspecs turns glob patterns into git pathspecs excluding the matching paths.
+// Patterns without a slash match at any depth, like in .gitignore.
+func excludePathspecs(patterns []string) []string {
+	pathspecs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+			pattern = "**/" + pattern
+		}
+		pathspecs = append(pathspecs, ":(top,exclude,glob)"+pattern)
+	}
+	return pathspecs
+}
+
+// commit is a single entry of the commit list.
+type commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Summary string `json:"summary,omitempty"` // one-line summary with -per-commit
+}
+
+// parseCommits splits "hash - subject" lines from git log into structured entries.
+// Output that isn't a commit list (e.g. the note for uncommitted changes) yields no entries.
+func parseCommits(log string, isCommitList bool) []commit {
+	commits := []commit{}
+	if !isCommitList {
+		return commits
+	}
+	for _, line := range strings.Split(log, "\n") {
+		if line == "" {
+			continue
+		}
+		hash, subject, _ := strings.Cut(line, " - ")
+		commits = append(commits, commit{Hash: hash, Subject: subject})
+	}
+	return commits
+}
+
+// capCommitList keeps the max most recent lines of a git log commit list, which lists the newest
+// commit first, and collapses the rest into a "(+N earlier commits)" line. It also returns the
+// collapsed commits. A max of 0 or less keeps every commit.
+func capCommitList(log string, max int) (string, []commit) {
+	lines := strings.Split(log, "\n")
+	if max <= 0 || len(lines) <= max {
+		return log, nil
+	}
+	earlier := parseCommits(strings.Join(lines[max:], "\n"), true)
+	note := fmt.Sprintf("(+%d earlier commits)", len(earlier))
+	if len(earlier) == 1 {
+		note = "(+1 earlier commit)"
+	}
+	return strings.Join(lines[:max], "\n") + "\n" + note, earlier
+}
+
+// commitTheme matches the type and optional scope of a Conventional Commits subject, e.g. "feat(parser):".
+var commitTheme = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?!?:`)
+
+// commitThemes groups commit subjects by their Conventional Commits type and scope and lists the
+// groups with their commit counts, largest first, e.g. "feat(parser) (5), fix (3), other (1)".
+func commitThemes(commits []commit) string {
+	counts := map[string]int{}
+	for _, c := range commits {
+		theme := "other"
+		if m := commitTheme.FindStringSubmatch(c.Subject); m != nil {
+			theme = strings.ToLower(m[1]) + m[2]
+		}
+		counts[theme]++
+	}
+
+	themes := make([]string, 0, len(counts))
+	for theme := range counts {
+		themes = append(themes, theme)
+	}
+	sort.Slice(themes, func(i, j int) bool {
+		if counts[themes[i]] != counts[themes[j]] {
+			return counts[themes[i]] > counts[themes[j]]
+		}
+		return themes[i] < themes[j]
+	})
+	for i, theme := range themes {
+		themes[i] = fmt.Sprintf("%s (%d)", theme, counts[theme])
+	}
+	return strings.Join(themes, ", ")
+}
@@ -0,0 +1,73 @@
+package summarizer
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestResolveBaseBranch(t *testing.T) {
+	tests := []struct {
+		name       string
+		commands   map[string]string
+		candidates []string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "origin/HEAD with local branch",
+			commands: map[string]string{
+				"git rev-parse --abbrev-ref --verify --quiet origin/HEAD": "origin/trunk",
+				"git rev-parse --verify --quiet trunk^{commit}":           "abc123",
+			},
+			want: "trunk",
+		},
+		{
+			name: "origin/HEAD without local branch",
+			commands: map[string]string{
+				"git rev-parse --abbrev-ref --verify --quiet origin/HEAD": "origin/trunk",
+			},
+			want: "origin/trunk",
+		},
+		{
+			name: "falls back to master",
+			commands: map[string]string{
+				"git rev-parse --verify --quiet master^{commit}":  "abc123",
+				"git rev-parse --verify --quiet develop^{commit}": "def456",
+			},
+			want: "master",
+		},
+		{
+			name: "configured candidates",
+			commands: map[string]string{
+				"git rev-parse --verify --quiet main^{commit}":    "abc123",
+				"git rev-parse --verify --quiet release^{commit}": "def456",
+			},
+			candidates: []string{"release", "main"},
+			want:       "release",
+		},
+		{
+			name:     "no candidate exists",
+			commands: map[string]string{},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCommands(t, tt.commands)
+			originalFlags, originalCandidates := cmdFlags, baseCandidates
+			cmdFlags, baseCandidates = flag.NewFlagSet("test", flag.ContinueOnError), tt.candidates
+			t.Cleanup(func() {
+				cmdFlags, baseCandidates = originalFlags, originalCandidates
+			})
+
+			got, err := resolveBaseBranch("")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveBaseBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveBaseBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// checkGHReady verifies that the gh CLI is installed and logged in to GitHub.
+func checkGHReady() error {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return errors.New("gh is not installed or not in PATH (see https://cli.github.com)")
+	}
+	if output, err := exec.Command("gh", "auth", "status").CombinedOutput(); err != nil {
+		return fmt.Errorf("gh is not authenticated, run 'gh auth login': %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// defaultPRTitle returns the subject of the most recent commit in the base..head range.
+func defaultPRTitle(base, head string) (string, error) {
+	title, err := getCommandOutput("git", "log", "-1", "--pretty=format:%s", base+".."+head)
+	if err != nil {
+		return "", err
+	}
+	if title == "" {
+		return "", errors.New("no commit to derive a title from, use -title")
+	}
+	return title, nil
+}
+
+// createPullRequest opens a pull request from head into base with gh pr create and returns its URL.
+// Remote-tracking base refs such as origin/main are passed to gh as the plain branch name.
+func createPullRequest(base, head, title, body string) (string, error) {
+	base = strings.TrimPrefix(base, "origin/")
+	cmd := exec.Command("gh", "pr", "create", "--base", base, "--head", head, "--title", title, "--body-file", "-")
+	cmd.Stdin = strings.NewReader(body)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gh pr create failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,201 @@
+package summarizer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Markers delimiting the section of a git hook script that install-hook manages. Everything
+// outside them belongs to the user and is kept by both install-hook and uninstall-hook.
+const (
+	hookStartMarker = "# >>> prgpt hook >>>"
+	hookEndMarker   = "# <<< prgpt hook <<<"
+)
+
+// hookShebang starts the hook scripts install-hook creates.
+const hookShebang = "#!/bin/sh\n"
+
+// hookScripts are the hook types install-hook supports and the script of each, which runs
+// ${PRGPT:-prgpt} and never fails the git command it hooks into.
+var hookScripts = map[string]string{
+	// Fills in the messages git starts empty, not those of -m, -F, merges, squashes or amends, with
+	// the one-line commit message of the staged changes
+	"prepare-commit-msg": `case "$2" in
+"" | template)
+	if [ -z "$PRGPT_SKIP_HOOK" ] && command -v "${PRGPT:-prgpt}" >/dev/null 2>&1; then
+		if summary=$("${PRGPT:-prgpt}" commit-msg -quiet </dev/null) && [ -n "$summary" ]; then
+			{ printf '%s\n' "$summary"; cat "$1"; } >"$1.prgpt" && mv "$1.prgpt" "$1"
+		fi
+	fi
+	;;
+esac
+`,
+	// Writes the PR description of the pushed branch to .git/prgpt-pr.md; stdin is left to the rest of the hook
+	"pre-push": `if [ -z "$PRGPT_SKIP_HOOK" ] && command -v "${PRGPT:-prgpt}" >/dev/null 2>&1; then
+	description=$(git rev-parse --git-path prgpt-pr.md)
+	if "${PRGPT:-prgpt}" pr -quiet -force -output "$description" </dev/null; then
+		echo "prgpt: PR description written to $description" >&2
+	fi
+fi
+`,
+}
+
+// hookSection returns the managed section install-hook writes into a hook of hookType.
+func hookSection(hookType string) string {
+	return hookStartMarker + "\n" +
+		"# Installed by prgpt install-hook and removed by prgpt uninstall-hook. Set PRGPT_SKIP_HOOK=1 to skip it.\n" +
+		hookScripts[hookType] +
+		hookEndMarker + "\n"
+}
+
+// errForeignHook is returned by installHookContent for a hook prgpt didn't install.
+var errForeignHook = errors.New("a hook not managed by prgpt exists, use -force to overwrite it")
+
+// cutHookSection splits content around its managed section, reporting false when there is none.
+func cutHookSection(content string) (before, after string, ok bool) {
+	start := strings.Index(content, hookStartMarker)
+	if start < 0 {
+		return "", "", false
+	}
+	end := strings.Index(content[start:], hookEndMarker)
+	if end < 0 {
+		return "", "", false
+	}
+	after = strings.TrimPrefix(content[start+end+len(hookEndMarker):], "\n")
+	return content[:start], after, true
+}
+
+// installHookContent returns the hook script with section installed into the existing script.
+// A managed section already in it is replaced in place, and a script without one is only
+// overwritten with force.
+func installHookContent(existing, section string, force bool) (string, error) {
+	if before, after, ok := cutHookSection(existing); ok {
+		return before + section + after, nil
+	}
+	if strings.TrimSpace(existing) != "" && !force {
+		return "", errForeignHook
+	}
+	return hookShebang + "\n" + section, nil
+}
+
+// removeHookSection returns the hook script without its managed section, or "" when nothing but
+// the shebang would be left. It reports false when there is no managed section.
+func removeHookSection(content string) (string, bool) {
+	before, after, ok := cutHookSection(content)
+	if !ok {
+		return "", false
+	}
+	rest := strings.TrimRight(before, "\n") + "\n"
+	if after != "" {
+		rest += "\n" + after
+	}
+	if strings.TrimSpace(strings.TrimPrefix(rest, hookShebang)) == "" {
+		return "", true
+	}
+	return rest, true
+}
+
+// hookFlagSet returns the flag set of install-hook or uninstall-hook and its -type flag.
+func hookFlagSet(name string) (*flag.FlagSet, *string) {
+	types := make([]string, 0, len(hookScripts))
+	for hookType := range hookScripts {
+		types = append(types, hookType)
+	}
+	sort.Strings(types)
+
+	fs := flag.NewFlagSet("prgpt "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prgpt %s [flags]\n\nFlags:\n", name)
+		fs.PrintDefaults()
+	}
+	hookType := fs.String("type", "prepare-commit-msg", "hook to "+strings.TrimSuffix(name, "-hook")+": "+strings.Join(types, " or "))
+	return fs, hookType
+}
+
+// hookPath returns the path of the hook script of hookType in the hooks directory of the
+// repository, which honors core.hooksPath.
+func hookPath(hookType string) (string, error) {
+	if _, ok := hookScripts[hookType]; !ok {
+		return "", fail(exitConfig, "Error: -type must be prepare-commit-msg or pre-push")
+	}
+	if !insideWorkTree() {
+		return "", fail(exitFailure, "prgpt must be run inside a git repository")
+	}
+	dir, err := getCommandOutput("git", "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fail(exitFailure, "Error finding the hooks directory: %v", err)
+	}
+	return filepath.Join(strings.TrimSpace(dir), hookType), nil
+}
+
+// runInstallHook implements the install-hook subcommand, writing the managed section into a git hook.
+func runInstallHook(args []string) error {
+	fs, hookType := hookFlagSet("install-hook")
+	force := fs.Bool("force", false, "overwrite an existing hook that prgpt didn't install")
+	fs.Parse(args)
+
+	path, err := hookPath(*hookType)
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fail(exitFailure, "Error reading %s: %v", path, err)
+	}
+	content, err := installHookContent(string(existing), hookSection(*hookType), *force)
+	if err != nil {
+		return fail(exitFailure, "Error: %s: %v", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fail(exitFailure, "Error creating the hooks directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		return fail(exitFailure, "Error writing %s: %v", path, err)
+	}
+	// WriteFile keeps the mode of an existing file, which may not be executable
+	if err := os.Chmod(path, 0o755); err != nil {
+		return fail(exitFailure, "Error making %s executable: %v", path, err)
+	}
+	warnf("Installed the prgpt %s hook in %s", *hookType, path)
+	return nil
+}
+
+// runUninstallHook implements the uninstall-hook subcommand, removing the managed section from a
+// git hook and the hook itself when nothing else is left in it.
+func runUninstallHook(args []string) error {
+	fs, hookType := hookFlagSet("uninstall-hook")
+	fs.Parse(args)
+
+	path, err := hookPath(*hookType)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fail(exitFailure, "Error: no %s hook installed", *hookType)
+	}
+	if err != nil {
+		return fail(exitFailure, "Error reading %s: %v", path, err)
+	}
+	rest, ok := removeHookSection(string(content))
+	if !ok {
+		return fail(exitFailure, "Error: %s is not managed by prgpt", path)
+	}
+
+	if rest == "" {
+		err = os.Remove(path)
+	} else {
+		err = os.WriteFile(path, []byte(rest), 0o755)
+	}
+	if err != nil {
+		return fail(exitFailure, "Error updating %s: %v", path, err)
+	}
+	warnf("Removed the prgpt %s hook from %s", *hookType, path)
+	return nil
+}
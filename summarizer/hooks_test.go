@@ -0,0 +1,81 @@
+package summarizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHookScripts(t *testing.T) {
+	tests := []struct {
+		hook string
+		runs string
+	}{
+		{hook: "prepare-commit-msg", runs: `"${PRGPT:-prgpt}" commit-msg -quiet`},
+		{hook: "pre-push", runs: `"${PRGPT:-prgpt}" pr -quiet`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hook, func(t *testing.T) {
+			if script := hookScripts[tt.hook]; !strings.Contains(script, tt.runs) {
+				t.Fatalf("%s hook = %q, want it to run %s", tt.hook, script, tt.runs)
+			}
+		})
+	}
+}
+
+func TestInstallHookContent(t *testing.T) {
+	section := hookStartMarker + "\nnew\n" + hookEndMarker + "\n"
+	tests := []struct {
+		name     string
+		existing string
+		force    bool
+		want     string
+		wantErr  error
+	}{
+		{name: "no hook", existing: "", want: "#!/bin/sh\n\n" + section},
+		{
+			name:     "managed section replaced in place",
+			existing: "#!/bin/sh\nbefore\n" + hookStartMarker + "\nold\n" + hookEndMarker + "\nafter\n",
+			want:     "#!/bin/sh\nbefore\n" + section + "after\n",
+		},
+		{name: "foreign hook", existing: "#!/bin/sh\necho mine\n", wantErr: errForeignHook},
+		{name: "foreign hook with force", existing: "#!/bin/sh\necho mine\n", force: true, want: "#!/bin/sh\n\n" + section},
+		{name: "unterminated section is foreign", existing: "#!/bin/sh\n" + hookStartMarker + "\nold\n", wantErr: errForeignHook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := installHookContent(tt.existing, section, tt.force)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("installHookContent() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("installHookContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHookSection(t *testing.T) {
+	section := hookSection("pre-push")
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "only prgpt", content: "#!/bin/sh\n\n" + section, want: "", wantOK: true},
+		{name: "user lines kept", content: "#!/bin/sh\necho before\n\n" + section + "echo after\n", want: "#!/bin/sh\necho before\n\necho after\n", wantOK: true},
+		{name: "not managed", content: "#!/bin/sh\necho mine\n", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := removeHookSection(tt.content)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("removeHookSection() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
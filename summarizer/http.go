@@ -0,0 +1,278 @@
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultHTTPTimeout is the default timeout applied to every API request.
+const defaultHTTPTimeout = 120 * time.Second
+
+// httpClient is the shared HTTP client used for all API calls.
+var httpClient = &http.Client{Timeout: defaultHTTPTimeout, Transport: proxyTransport()}
+
+// proxyTransport returns the default transport set to use the proxy of the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables. Requests to localhost, like those to Ollama, are never proxied.
+func proxyTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
+// envTimeout returns the timeout configured through PRGPT_TIMEOUT, or the default when unset.
+// The value is either a Go duration ("90s", "2m") or a number of seconds.
+func envTimeout() (time.Duration, error) {
+	value, ok := os.LookupEnv("PRGPT_TIMEOUT")
+	if !ok || value == "" {
+		return defaultHTTPTimeout, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PRGPT_TIMEOUT %q: %v", value, err)
+	}
+	return timeout, nil
+}
+
+// requestError describes a failed call to the named API, calling out timeouts explicitly.
+func requestError(api string, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%s request cancelled", api)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%s request timed out after %s", api, httpClient.Timeout)
+	}
+	return fmt.Errorf("error calling %s: %w", api, err)
+}
+
+// isUnreachable reports whether err means the server couldn't be connected to at all.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// ollamaUnavailable marks err with ErrOllamaUnavailable when it means Ollama couldn't be connected to.
+func ollamaUnavailable(err error) error {
+	if isUnreachable(err) {
+		return fmt.Errorf("%w (%w)", ErrOllamaUnavailable, err)
+	}
+	return err
+}
+
+// statusError reports a non-2xx response from an API, including the start of the response body.
+type statusError struct {
+	api        string
+	statusCode int
+	body       []byte
+	retryAfter time.Duration // wait requested by a Retry-After header on 429 and 529 responses, 0 if none
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d %s: %s", e.api, e.statusCode, http.StatusText(e.statusCode), bodySnippet(e.body))
+}
+
+// Unwrap returns ErrProviderAuth or ErrProviderRateLimited for the statuses that mean them.
+func (e *statusError) Unwrap() error {
+	switch e.statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrProviderAuth
+	case http.StatusTooManyRequests:
+		return ErrProviderRateLimited
+	}
+	return nil
+}
+
+// newJSONRequest creates a POST request sending the given JSON body to url.
+func newJSONRequest(ctx context.Context, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// maxRetries is the number of times a transiently failing API request is retried.
+var maxRetries = 3
+
+// retryBaseDelay is the delay before the first retry; it doubles with every further attempt.
+var retryBaseDelay = time.Second
+
+// maxRetryWait caps the wait a Retry-After header can ask for.
+var maxRetryWait = 60 * time.Second
+
+// parseRetryAfter returns the wait requested by a Retry-After header value, given either as a number
+// of seconds or as an HTTP date. It returns 0 for a missing, invalid or past value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// isRetryableStatus reports whether a response status code indicates a transient failure.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, 529: // 529 is Anthropic's "overloaded" status
+		return true
+	}
+	return false
+}
+
+// doWithRetry sends the request built by newRequest to the named API and returns the response status and body.
+// Network errors and retryable statuses are retried up to maxRetries times with exponential backoff.
+// Any other non-2xx response fails immediately with a *statusError.
+func doWithRetry(ctx context.Context, api string, newRequest func() (*http.Request, error)) (int, []byte, error) {
+	var status int
+	var body []byte
+	err := retry(ctx, api, func() (bool, error) {
+		var retryable bool
+		var err error
+		status, body, retryable, err = doOnce(api, newRequest)
+		return retryable, err
+	})
+	return status, body, err
+}
+
+// doStreamWithRetry sends the streaming request built by newRequest to the named API and returns the
+// 2xx response once its status arrives, retrying like doWithRetry until then. The caller reads and
+// closes the body, which httpClient.Timeout doesn't cover: a long stream is only cut off by ctx.
+func doStreamWithRetry(ctx context.Context, api string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	client := streamClient()
+	var resp *http.Response
+	err := retry(ctx, api, func() (bool, error) {
+		var retryable bool
+		var err error
+		resp, retryable, err = streamOnce(api, client, newRequest)
+		return retryable, err
+	})
+	return resp, err
+}
+
+// retry runs attempt until it succeeds or fails with an error it doesn't report as retryable, at most
+// maxRetries times more, waiting with exponential backoff or the Retry-After of a rate limited API.
+func retry(ctx context.Context, api string, attempt func() (retryable bool, err error)) error {
+	delay := retryBaseDelay
+	for n := 0; ; n++ {
+		retryable, err := attempt()
+		if !retryable || n >= maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		// A rate limited API says how long to wait, which replaces the backoff for this attempt
+		wait := delay
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.retryAfter > 0 {
+			wait = min(statusErr.retryAfter, maxRetryWait)
+			logf("%s asked to retry after %s, waiting %s", api, statusErr.retryAfter, wait)
+		}
+
+		logf("Retrying %s in %s (attempt %d/%d): %v", api, wait, n+1, maxRetries, err)
+		select {
+		case <-ctx.Done():
+			return requestError(api, ctx.Err())
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// doOnce performs a single attempt of an API request and reports whether a failure is worth retrying.
+func doOnce(api string, newRequest func() (*http.Request, error)) (int, []byte, bool, error) {
+	req, err := newRequest()
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("error creating request: %v", err)
+	}
+
+	logf("%s: %s %s (%d bytes)", api, req.Method, req.URL, req.ContentLength)
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = requestError(api, err)
+		apiLog.record(api, req, start, 0, nil, false, err)
+		return 0, nil, true, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("error reading %s response: %v", api, err)
+		apiLog.record(api, req, start, resp.StatusCode, nil, false, err)
+		return resp.StatusCode, nil, true, err
+	}
+	apiLog.record(api, req, start, resp.StatusCode, body, false, nil)
+	logf("%s: status %d (%d bytes) in %s", api, resp.StatusCode, len(body), time.Since(start).Round(time.Millisecond))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := &statusError{api: api, statusCode: resp.StatusCode, body: body}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 529 {
+			statusErr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return resp.StatusCode, body, isRetryableStatus(resp.StatusCode), statusErr
+	}
+	return resp.StatusCode, body, false, nil
+}
+
+// streamClient returns a client for event streams: httpClient without a timeout for the body, but
+// still timing out after httpClient.Timeout while waiting for the response status.
+func streamClient() *http.Client {
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return &http.Client{Transport: httpClient.Transport}
+	}
+	transport = transport.Clone()
+	transport.ResponseHeaderTimeout = httpClient.Timeout
+	return &http.Client{Transport: transport}
+}
+
+// streamOnce performs a single attempt of a streaming API request and returns the response if its
+// status is 2xx, reporting whether a failure is worth retrying.
+func streamOnce(api string, client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, bool, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating request: %v", err)
+	}
+
+	logf("%s: %s %s (%d bytes, streaming)", api, req.Method, req.URL, req.ContentLength)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		err = requestError(api, err)
+		apiLog.record(api, req, start, 0, nil, true, err)
+		return nil, true, err
+	}
+	logf("%s: status %d after %s", api, resp.StatusCode, time.Since(start).Round(time.Millisecond))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		apiLog.record(api, req, start, resp.StatusCode, body, true, nil)
+		statusErr := &statusError{api: api, statusCode: resp.StatusCode, body: body}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 529 {
+			statusErr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, isRetryableStatus(resp.StatusCode), statusErr
+	}
+	return resp, false, nil
+}
@@ -0,0 +1,133 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "7", want: 7 * time.Second},
+		{name: "negative seconds", value: "-3", want: 0},
+		{name: "HTTP date", value: "Wed, 01 May 2024 12:00:30 GMT", want: 30 * time.Second},
+		{name: "past HTTP date", value: "Wed, 01 May 2024 11:59:00 GMT", want: 0},
+		{name: "invalid", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	originalRetries, originalWait := maxRetries, maxRetryWait
+	maxRetries, maxRetryWait = 1, 10*time.Millisecond
+	t.Cleanup(func() {
+		maxRetries, maxRetryWait = originalRetries, originalWait
+	})
+
+	start := time.Now()
+	status, _, err := doWithRetry(context.Background(), "Test API", func() (*http.Request, error) {
+		return newJSONRequest(context.Background(), server.URL, []byte(`{}`))
+	})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("doWithRetry() = %d, %v, want 200", status, err)
+	}
+	if calls != 2 {
+		t.Fatalf("server got %d calls, want 2", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("doWithRetry() waited %s, want the wait capped at -max-retry-wait", elapsed)
+	}
+}
+
+func TestDoStreamWithRetry(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(529)
+			return
+		}
+		// The stream takes longer than the timeout, which only covers waiting for the status
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("data: done\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	originalRetries, originalDelay, originalTimeout := maxRetries, retryBaseDelay, httpClient.Timeout
+	maxRetries, retryBaseDelay, httpClient.Timeout = 1, time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() {
+		maxRetries, retryBaseDelay, httpClient.Timeout = originalRetries, originalDelay, originalTimeout
+	})
+
+	resp, err := doStreamWithRetry(context.Background(), "Test API", func() (*http.Request, error) {
+		return newJSONRequest(context.Background(), server.URL, []byte(`{}`))
+	})
+	if err != nil {
+		t.Fatalf("doStreamWithRetry() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if calls != 2 {
+		t.Fatalf("server got %d calls, want 2", calls)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "data: done\n" {
+		t.Errorf("stream = %q, %v, want the whole stream", body, err)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "unauthorized status", err: &statusError{api: "OpenAI API", statusCode: http.StatusUnauthorized}, want: ErrProviderAuth},
+		{name: "rate limited status", err: &statusError{api: "OpenAI API", statusCode: http.StatusTooManyRequests}, want: ErrProviderRateLimited},
+		{name: "Anthropic authentication error", err: &anthropicError{statusCode: 401, errType: "authentication_error"}, want: ErrProviderAuth},
+		{name: "Anthropic rate limit error", err: &anthropicError{statusCode: 429, errType: "rate_limit_error"}, want: ErrProviderRateLimited},
+		{name: "wrapped in an exit error", err: fail(exitAPI, "Error generating summary: %w", &statusError{statusCode: http.StatusForbidden}), want: ErrProviderAuth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", tt.err, tt.want)
+			}
+		})
+	}
+
+	if err := (&statusError{statusCode: http.StatusBadRequest}); errors.Is(err, ErrProviderAuth) || errors.Is(err, ErrProviderRateLimited) {
+		t.Errorf("status 400 = %v, want neither auth nor rate limit error", err)
+	}
+}
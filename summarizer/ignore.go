@@ -0,0 +1,155 @@
+package summarizer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileName is the file at the repository root whose gitignore-style patterns are left out of the diff.
+const ignoreFileName = ".prgptignore"
+
+// ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // a "!pattern" re-including paths an earlier rule ignored
+	dirOnly bool // a "pattern/" only matching directories
+}
+
+// loadIgnoreFile reads the ignore file of the repository at repoRoot. A missing file has no rules.
+func loadIgnoreFile(repoRoot string) ([]ignoreRule, error) {
+	if repoRoot == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(filepath.Join(repoRoot, ignoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIgnoreFile(string(content))
+}
+
+// parseIgnoreFile parses ignore patterns in gitignore syntax: blank lines and "#" comments are
+// skipped, "!" negates a pattern, a trailing "/" matches directories only, and a pattern with a
+// slash anywhere else is relative to the repository root instead of matching at any depth.
+func parseIgnoreFile(content string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		expr := globRegexp(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %v", lineNo, scanner.Text(), err)
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// globRegexp translates a gitignore glob into a regular expression: "*" and "?" don't match "/",
+// while "**" matches across directories.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// isIgnored reports whether the rules ignore path. Like git, a path inside an ignored directory
+// stays ignored even if a later rule re-includes the path itself.
+func isIgnored(rules []ignoreRule, path string) bool {
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if matchIgnoreRules(rules, strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return matchIgnoreRules(rules, path, false)
+}
+
+// matchIgnoreRules applies the rules to a single path in order; the last matching rule decides.
+func matchIgnoreRules(rules []ignoreRule, path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// ignoredPathspecs returns pathspecs excluding the paths the rules ignore, each matched literally.
+func ignoredPathspecs(rules []ignoreRule, paths []string) []string {
+	var pathspecs []string
+	for _, path := range paths {
+		if isIgnored(rules, path) {
+			pathspecs = append(pathspecs, ":(top,exclude,literal)"+path)
+		}
+	}
+	return pathspecs
+}
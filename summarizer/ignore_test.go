@@ -0,0 +1,45 @@
+package summarizer
+
+import "testing"
+
+func TestIsIgnored(t *testing.T) {
+	rules, err := parseIgnoreFile(`# generated code
+*.lock
+/dist
+docs/**/*.png
+build/
+!build/keep.txt
+*.gen.go
+!api.gen.go
+\#notes
+`)
+	if err != nil {
+		t.Fatalf("parseIgnoreFile() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "go.lock", want: true},
+		{path: "web/yarn.lock", want: true},
+		{path: "dist", want: true},
+		{path: "dist/app.js", want: true},
+		{path: "web/dist/app.js", want: false},
+		{path: "docs/img/a.png", want: true},
+		{path: "docs/a.png", want: true},
+		{path: "src/a.png", want: false},
+		{path: "build", want: false},
+		{path: "build/out.o", want: true},
+		{path: "build/keep.txt", want: true}, // its directory stays ignored
+		{path: "models.gen.go", want: true},
+		{path: "pkg/api.gen.go", want: false},
+		{path: "#notes", want: true},
+		{path: "main.go", want: false},
+	}
+	for _, tt := range tests {
+		if got := isIgnored(rules, tt.path); got != tt.want {
+			t.Errorf("isIgnored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
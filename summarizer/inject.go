@@ -0,0 +1,47 @@
+package summarizer
+
+import (
+	"os"
+	"strings"
+)
+
+// Markers delimiting the section of a file that -inject-into replaces with the summary.
+const (
+	summaryStartMarker = "<!-- prgpt:summary -->"
+	summaryEndMarker   = "<!-- /prgpt:summary -->"
+)
+
+// injectSummary replaces the text between the summary markers in content with summary. When the
+// markers are missing it appends the summary wrapped in markers, so the next run replaces it,
+// and reports false.
+func injectSummary(content, summary string) (string, bool) {
+	if start := strings.Index(content, summaryStartMarker); start >= 0 {
+		afterStart := start + len(summaryStartMarker)
+		if end := strings.Index(content[afterStart:], summaryEndMarker); end >= 0 {
+			return content[:afterStart] + "\n" + summary + "\n" + content[afterStart+end:], true
+		}
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + summaryStartMarker + "\n" + summary + "\n" + summaryEndMarker + "\n", false
+}
+
+// injectIntoFile writes summary into the marked section of the file at path, keeping the rest of it.
+func injectIntoFile(path, summary string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	injected, found := injectSummary(string(content), summary)
+	if !found {
+		warnf("Warning: no %s ... %s markers in %s, appending the summary", summaryStartMarker, summaryEndMarker, path)
+	}
+	return os.WriteFile(path, []byte(injected), info.Mode().Perm())
+}
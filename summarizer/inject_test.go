@@ -0,0 +1,91 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInjectSummary(t *testing.T) {
+	const start, end = summaryStartMarker, summaryEndMarker
+	tests := []struct {
+		name      string
+		content   string
+		summary   string
+		want      string
+		wantFound bool
+	}{
+		{
+			name:      "markers replaced",
+			content:   "## Summary\n" + start + "\nold summary\n" + end + "\n\n## Checklist\n",
+			summary:   "new summary",
+			want:      "## Summary\n" + start + "\nnew summary\n" + end + "\n\n## Checklist\n",
+			wantFound: true,
+		},
+		{
+			name:      "empty marked section",
+			content:   start + end,
+			summary:   "new summary",
+			want:      start + "\nnew summary\n" + end,
+			wantFound: true,
+		},
+		{
+			name:      "only the first marked section",
+			content:   start + "\na\n" + end + "\n" + start + "\nb\n" + end + "\n",
+			summary:   "new",
+			want:      start + "\nnew\n" + end + "\n" + start + "\nb\n" + end + "\n",
+			wantFound: true,
+		},
+		{
+			name:    "no markers",
+			content: "## Checklist\n- [ ] tests",
+			summary: "new summary",
+			want:    "## Checklist\n- [ ] tests\n\n" + start + "\nnew summary\n" + end + "\n",
+		},
+		{
+			name:    "end marker without a start",
+			content: "body\n" + end + "\n",
+			summary: "new",
+			want:    "body\n" + end + "\n\n" + start + "\nnew\n" + end + "\n",
+		},
+		{
+			name:    "empty file",
+			summary: "new",
+			want:    "\n" + start + "\nnew\n" + end + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := injectSummary(tt.content, tt.summary)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("injectSummary() = %q, %v\nwant %q, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestInjectIntoFileKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pr.md")
+	if err := os.WriteFile(path, []byte("intro\n"+summaryStartMarker+"\nold\n"+summaryEndMarker+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := injectIntoFile(path, "new"); err != nil {
+		t.Fatalf("injectIntoFile() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "intro\n" + summaryStartMarker + "\nnew\n" + summaryEndMarker + "\n"; string(content) != want {
+		t.Errorf("file = %q, want %q", content, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
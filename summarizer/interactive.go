@@ -0,0 +1,65 @@
+package summarizer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Commands of the -interactive prompt; any other input is an instruction to revise the summary.
+const (
+	interactiveSave = ":save"
+	interactiveQuit = ":quit"
+)
+
+// refineInstruction wraps a follow-up instruction so the reply is only the revised summary.
+const refineInstruction = "Revise the summary: %s\n\nReply with the complete revised summary only."
+
+// refineInteractively reads follow-up instructions from in and sends each as another turn of the
+// conversation that started with prompt and summary, printing every revised summary to out. :save
+// passes the latest summary to save, and :quit or the end of the input ends the loop.
+func refineInteractively(ctx context.Context, provider ConversationProvider, prompt, summary string, in io.Reader, out io.Writer, save func(summary string) error) error {
+	messages := []chatMessage{{Role: "user", Content: prompt}, {Role: "assistant", Content: summary}}
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(os.Stderr, "\nRefine the summary (%s, %s)> ", interactiveSave, interactiveQuit)
+		if !scanner.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		switch input {
+		case "":
+			continue
+		case interactiveQuit:
+			return nil
+		case interactiveSave:
+			if err := save(summary); err != nil {
+				warnf("Error saving summary: %v", err)
+			}
+			continue
+		}
+
+		instruction := chatMessage{Role: "user", Content: fmt.Sprintf(refineInstruction, input)}
+		status.set("Revising summary")
+		done := metrics.track(phaseSummary)
+		reply, err := provider.Converse(ctx, append(messages, instruction))
+		done()
+		status.clear()
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		if err != nil {
+			// The failed instruction is left out of the conversation, so it can just be tried again
+			warnf("Error revising summary: %v", err)
+			continue
+		}
+		messages = append(messages, instruction, chatMessage{Role: "assistant", Content: reply})
+		summary = reply
+		fmt.Fprintln(out, summary)
+	}
+}
@@ -0,0 +1,58 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeConversation replies to every conversation with the number of messages it got.
+type fakeConversation struct {
+	conversations [][]chatMessage
+}
+
+func (f *fakeConversation) Summarize(ctx context.Context, prompt string) (string, error) {
+	return f.Converse(ctx, []chatMessage{{Role: "user", Content: prompt}})
+}
+
+func (f *fakeConversation) Converse(ctx context.Context, messages []chatMessage) (string, error) {
+	f.conversations = append(f.conversations, messages)
+	return fmt.Sprintf("summary after %d messages", len(messages)), nil
+}
+
+func TestRefineInteractively(t *testing.T) {
+	provider := &fakeConversation{}
+	var out strings.Builder
+	var saved []string
+	in := strings.NewReader("make it shorter\n\n:save\nfocus on the API\n:quit\nignored\n")
+
+	err := refineInteractively(context.Background(), provider, "prompt", "first summary", in, &out, func(summary string) error {
+		saved = append(saved, summary)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("refineInteractively() error = %v", err)
+	}
+
+	if got, want := out.String(), "summary after 3 messages\nsummary after 5 messages\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if len(saved) != 1 || saved[0] != "summary after 3 messages" {
+		t.Errorf("saved = %q, want the summary revised once", saved)
+	}
+	if len(provider.conversations) != 2 {
+		t.Fatalf("got %d conversations, want 2", len(provider.conversations))
+	}
+	last := provider.conversations[1]
+	roles := make([]string, len(last))
+	for i, message := range last {
+		roles[i] = message.Role
+	}
+	if got, want := strings.Join(roles, ","), "user,assistant,user,assistant,user"; got != want {
+		t.Errorf("roles = %s, want %s", got, want)
+	}
+	if last[1].Content != "first summary" || !strings.Contains(last[4].Content, "focus on the API") {
+		t.Errorf("conversation = %+v, want the first summary and the latest instruction", last)
+	}
+}
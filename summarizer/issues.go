@@ -0,0 +1,56 @@
+package summarizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultIssuePatterns match GitHub style "#123" and Jira style "PROJ-456" issue references.
+var defaultIssuePatterns = []string{`#\d+`, `[A-Z]+-\d+`}
+
+// issuePatterns overrides defaultIssuePatterns when set with -issue-pattern or the config file.
+var issuePatterns stringListFlag
+
+// compileIssuePatterns compiles the configured issue reference patterns.
+func compileIssuePatterns() ([]*regexp.Regexp, error) {
+	patterns := []string(issuePatterns)
+	if len(patterns) == 0 {
+		patterns = defaultIssuePatterns
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue pattern %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// extractIssueRefs returns the unique issue references found in texts, in order of appearance.
+func extractIssueRefs(texts []string, patterns []*regexp.Regexp) []string {
+	var refs []string
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, re := range patterns {
+			for _, ref := range re.FindAllString(text, -1) {
+				if !seen[ref] {
+					seen[ref] = true
+					refs = append(refs, ref)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// issueList formats issue references as a markdown list with the closing syntax of prPlatform.
+func issueList(refs []string) string {
+	lines := make([]string, len(refs))
+	for i, ref := range refs {
+		lines[i] = "- " + closingReference(prPlatform, ref)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,86 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// localMode generates the summary with Ollama too, with -local, so no diff leaves the machine.
+var localMode bool
+
+// ollamaSummaryModel is the Ollama model of -provider ollama; empty uses the compression model.
+var ollamaSummaryModel = ""
+
+// ollamaContextReserve is added to the estimated prompt size for the context window of an Ollama
+// summary, which Ollama otherwise truncates prompts to without an error.
+const ollamaContextReserve = 1024
+
+// ollamaProvider generates summaries with a local Ollama model, calling the generate API.
+type ollamaProvider struct {
+	model     string
+	maxTokens int
+}
+
+// summaryModel returns the Ollama model used for the summary.
+func summaryModel() string {
+	if ollamaSummaryModel != "" {
+		return ollamaSummaryModel
+	}
+	return ollamaCompletionModel
+}
+
+// applyLocalMode switches to -provider ollama for -local. providerSet reports whether -provider
+// was given on the command line, which can't name any other provider then.
+func applyLocalMode(o *options, providerSet bool) error {
+	if !localMode {
+		return nil
+	}
+	if providerSet && o.providerName != "ollama" {
+		return fmt.Errorf("-local cannot be combined with -provider %s", o.providerName)
+	}
+	o.providerName = "ollama"
+	return nil
+}
+
+// Summarize sends the prompt to the Ollama generate API and returns the generated text.
+func (p *ollamaProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	return p.generate(ctx, prompt, nil)
+}
+
+// SummarizeStructured sends the prompt with structuredSchema as the format, which Ollama constrains
+// the output to.
+func (p *ollamaProvider) SummarizeStructured(ctx context.Context, prompt string) (string, error) {
+	return p.generate(ctx, prompt, structuredSchema)
+}
+
+// generate sends the prompt to the Ollama generate API, with the output format if one is given.
+func (p *ollamaProvider) generate(ctx context.Context, prompt string, format interface{}) (string, error) {
+	options := map[string]interface{}{
+		"num_ctx":     estimateTokens(systemPrompt+prompt) + p.maxTokens + ollamaContextReserve,
+		"num_predict": p.maxTokens,
+	}
+	addSamplingParams(options, "temperature", "top_p")
+	requestBody, err := json.Marshal(OllamaCompletionRequest{
+		Model:   p.model,
+		Prompt:  prompt,
+		System:  systemPrompt,
+		Format:  format,
+		Stream:  false,
+		Options: options,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	_, body, err := doWithRetry(ctx, "Ollama completion API", func() (*http.Request, error) {
+		return newJSONRequest(ctx, ollamaCompletionURL, requestBody)
+	})
+	if err != nil {
+		return "", ollamaModelError(err, p.model)
+	}
+
+	recordResponseUsage(body)
+	return decodeOllamaCompletionResponse(body)
+}
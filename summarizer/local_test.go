@@ -0,0 +1,73 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOllamaSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "success", status: http.StatusOK, body: `{"response":"a summary","done":true,"prompt_eval_count":12,"eval_count":3}`, want: "a summary"},
+		{name: "model not pulled", status: http.StatusNotFound, body: `{"error":"model \"qwen\" not found, try pulling it first"}`, wantErr: "ollama pull qwen"},
+		{name: "malformed JSON", status: http.StatusOK, body: `not json`, wantErr: "unexpected Ollama response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &ollamaCompletionURL, tt.status, tt.body)
+
+			provider := &ollamaProvider{model: "qwen", maxTokens: anthropicMaxTokens}
+			got, err := provider.Summarize(context.Background(), "prompt")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Summarize() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Summarize() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLocalMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       bool
+		provider    string
+		providerSet bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "off", provider: "anthropic", want: "anthropic"},
+		{name: "default provider replaced", local: true, provider: "anthropic", want: "ollama"},
+		{name: "explicit ollama", local: true, provider: "ollama", providerSet: true, want: "ollama"},
+		{name: "explicit cloud provider", local: true, provider: "openai", providerSet: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localMode = tt.local
+			defer func() { localMode = false }()
+
+			o := &options{providerName: tt.provider}
+			err := applyLocalMode(o, tt.providerSet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyLocalMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && o.providerName != tt.want {
+				t.Fatalf("provider = %q, want %q", o.providerName, tt.want)
+			}
+		})
+	}
+}
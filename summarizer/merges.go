@@ -0,0 +1,57 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// countMerges returns the number of merge commits git log finds for logArgs and pathArgs.
+// A failing git log counts as none, like for the commit list.
+func countMerges(logArgs, pathArgs []string) int {
+	hashes, err := getCommandOutput("git", append(append([]string{"log", "--merges", "--pretty=format:%h"}, logArgs...), pathArgs...)...)
+	if err != nil || hashes == "" {
+		return 0
+	}
+	return strings.Count(hashes, "\n") + 1
+}
+
+// mergeNote is the note the PR template shows about merge commits in the range, or "" without any.
+func mergeNote(count int, excluded bool) string {
+	switch {
+	case count == 0:
+		return ""
+	case excluded:
+		return fmt.Sprintf("%s left out of the commits and changes", countNoun(count, "merge commit"))
+	}
+	return fmt.Sprintf("Includes %s, use -no-merges to summarize only the other commits", countNoun(count, "merge commit"))
+}
+
+// nonMergeDiff returns the changes of the non-merge commits selected by args, commit by commit from the
+// oldest, and the statistics of every file summed over them. Changes merged in from other branches
+// aren't part of it, while a file changed by several commits appears once per commit.
+func nonMergeDiff(extraDiffArgs, args []string) (string, []FileStat, error) {
+	logArgs := append([]string{"log", "-p", "--no-merges", "--reverse", "--no-ext-diff", "--format="}, extraDiffArgs...)
+	diff, err := getCommandOutput("git", append(logArgs, args...)...)
+	if err != nil {
+		return "", nil, err
+	}
+	return diff, sumFileStats(diffFileStats(diff)), nil
+}
+
+// sumFileStats merges the statistics of files listed more than once, keeping the order of their first entry.
+func sumFileStats(stats []FileStat) []FileStat {
+	summed := []FileStat{}
+	index := map[string]int{}
+	for _, stat := range stats {
+		i, ok := index[stat.Path]
+		if !ok {
+			index[stat.Path] = len(summed)
+			summed = append(summed, stat)
+			continue
+		}
+		summed[i].Added += stat.Added
+		summed[i].Deleted += stat.Deleted
+		summed[i].Binary = summed[i].Binary || stat.Binary
+	}
+	return summed
+}
@@ -0,0 +1,35 @@
+package summarizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNonMergeDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1,2 @@\n a\n+b\n\n" +
+		"diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n@@ -1 +1 @@\n-x\n+y\n\n" +
+		"diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	fakeCommands(t, map[string]string{
+		"git log -p --no-merges --reverse --no-ext-diff --format= main..feature": diff,
+		"git log --merges --pretty=format:%h main..feature":                      "abc1234\ndef5678",
+	})
+
+	got, stats, err := nonMergeDiff(nil, []string{"main..feature"})
+	if err != nil {
+		t.Fatalf("nonMergeDiff() error = %v", err)
+	}
+	if got != diff {
+		t.Errorf("nonMergeDiff() diff = %q, want the git log output", got)
+	}
+	want := []FileStat{{Path: "a.go", Added: 2, Deleted: 1}, {Path: "b.go", Added: 1, Deleted: 1}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("nonMergeDiff() stats = %+v, want %+v", stats, want)
+	}
+
+	if got := countMerges([]string{"main..feature"}, nil); got != 2 {
+		t.Errorf("countMerges() = %d, want 2", got)
+	}
+	if got := countMerges([]string{"main..other"}, nil); got != 0 {
+		t.Errorf("countMerges() for a failing git log = %d, want 0", got)
+	}
+}
@@ -0,0 +1,122 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// showMetrics prints the timings and token usage of the run to stderr when it ends.
+var showMetrics bool
+
+// Phases the run time is broken down into by -metrics.
+const (
+	phaseGit         = "git"
+	phaseCompression = "Ollama compression"
+	phaseEmbeddings  = "Ollama embeddings"
+	phaseSummary     = "summary"
+)
+
+// metricPhases lists the phases in the order they are reported.
+var metricPhases = []string{phaseGit, phaseCompression, phaseEmbeddings, phaseSummary}
+
+// runMetrics collects the time spent in each phase and the tokens used by the summary calls.
+// Phases that run concurrently, like compression and raw embeddings, each count their full time.
+type runMetrics struct {
+	mu        sync.Mutex
+	start     time.Time
+	durations map[string]time.Duration
+	calls     map[string]int
+
+	inputTokens, outputTokens       int
+	usageReported                   bool
+	estimatedInput, estimatedOutput int // character-based estimates, reported when no provider reported usage
+}
+
+// newRunMetrics returns metrics for a run starting now.
+func newRunMetrics() *runMetrics {
+	return &runMetrics{start: time.Now(), durations: map[string]time.Duration{}, calls: map[string]int{}}
+}
+
+// metrics collects the metrics of this run.
+var metrics = newRunMetrics()
+
+// track starts timing a phase; calling the returned function stops it.
+func (m *runMetrics) track(phase string) func() {
+	start := time.Now()
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.durations[phase] += time.Since(start)
+		m.calls[phase]++
+	}
+}
+
+// addUsage records the token counts a provider reported for a response.
+func (m *runMetrics) addUsage(input, output int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputTokens += input
+	m.outputTokens += output
+	m.usageReported = true
+}
+
+// addEstimate records estimated token counts for a response whose provider reported no usage.
+func (m *runMetrics) addEstimate(prompt, summary string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.estimatedInput += estimateTokens(systemPrompt + prompt)
+	m.estimatedOutput += estimateTokens(summary)
+}
+
+// recordResponseUsage adds the token usage in an API response body to the metrics. It understands
+// the usage fields of the Anthropic (and Bedrock), OpenAI and Gemini APIs.
+func recordResponseUsage(body []byte) {
+	var result struct {
+		Usage struct {
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+		PromptEvalCount int `json:"prompt_eval_count"` // Ollama
+		EvalCount       int `json:"eval_count"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return
+	}
+	input := result.Usage.InputTokens + result.Usage.PromptTokens + result.UsageMetadata.PromptTokenCount + result.PromptEvalCount
+	output := result.Usage.OutputTokens + result.Usage.CompletionTokens + result.UsageMetadata.CandidatesTokenCount + result.EvalCount
+	if input > 0 || output > 0 {
+		metrics.addUsage(input, output)
+	}
+}
+
+// report writes the metrics footer to w.
+func (m *runMetrics) report(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("\nMetrics:\n")
+	for _, phase := range metricPhases {
+		if calls := m.calls[phase]; calls > 0 {
+			fmt.Fprintf(&b, "  %-20s %8s (%d calls)\n", phase, m.durations[phase].Round(time.Millisecond), calls)
+		}
+	}
+	fmt.Fprintf(&b, "  %-20s %8s\n", "total", time.Since(m.start).Round(time.Millisecond))
+	switch {
+	case m.usageReported:
+		fmt.Fprintf(&b, "  %-20s %d input, %d output\n", "tokens", m.inputTokens, m.outputTokens)
+	case m.estimatedInput > 0 || m.estimatedOutput > 0:
+		fmt.Fprintf(&b, "  %-20s ~%d input, ~%d output (estimated)\n", "tokens", m.estimatedInput, m.estimatedOutput)
+	}
+	fmt.Fprint(w, b.String())
+}
@@ -0,0 +1,61 @@
+package summarizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordResponseUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		input    int
+		output   int
+		reported bool
+	}{
+		{"anthropic", `{"usage":{"input_tokens":120,"output_tokens":30}}`, 120, 30, true},
+		{"openai", `{"usage":{"prompt_tokens":80,"completion_tokens":20}}`, 80, 20, true},
+		{"gemini", `{"usageMetadata":{"promptTokenCount":50,"candidatesTokenCount":10}}`, 50, 10, true},
+		{"no usage", `{"choices":[]}`, 0, 0, false},
+		{"invalid", `not json`, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := metrics
+			metrics = newRunMetrics()
+			t.Cleanup(func() { metrics = original })
+
+			recordResponseUsage([]byte(tt.body))
+			if metrics.inputTokens != tt.input || metrics.outputTokens != tt.output || metrics.usageReported != tt.reported {
+				t.Errorf("usage = %d input, %d output, reported %v, want %d, %d, %v",
+					metrics.inputTokens, metrics.outputTokens, metrics.usageReported, tt.input, tt.output, tt.reported)
+			}
+		})
+	}
+}
+
+func TestMetricsReport(t *testing.T) {
+	m := newRunMetrics()
+	m.track(phaseGit)()
+	m.track(phaseGit)()
+	m.addEstimate("", strings.Repeat("x", 400))
+
+	var b strings.Builder
+	m.report(&b)
+	report := b.String()
+	for _, want := range []string{"git", "(2 calls)", "total", "(estimated)"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, phaseSummary) {
+		t.Errorf("report lists a phase that never ran:\n%s", report)
+	}
+
+	m.addUsage(100, 25)
+	b.Reset()
+	m.report(&b)
+	if report := b.String(); !strings.Contains(report, "100 input, 25 output") || strings.Contains(report, "estimated") {
+		t.Errorf("report with reported usage:\n%s", report)
+	}
+}
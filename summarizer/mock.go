@@ -0,0 +1,77 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// mockProvider writes a deterministic summary from the commit subjects and file statistics
+// without calling any API, for demos and for testing the git and template handling offline.
+type mockProvider struct{}
+
+// offlineProvider is implemented by providers that summarize the changes themselves, so no
+// prompt has to be built and neither Ollama nor a model is called.
+type offlineProvider interface {
+	SummaryProvider
+	summarizeChanges(changes ChangeSet) string
+}
+
+// mockTopFiles is the number of most changed files the mock summary lists.
+const mockTopFiles = 5
+
+// Summarize returns a summary of the prompt itself, for the rare paths that only have a prompt.
+func (mockProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	return fmt.Sprintf("Mock summary of a %d character prompt.", len(prompt)), nil
+}
+
+// mockTitle returns the subject of the most recent commit of changes as the title, or a generic
+// title when there are no commits.
+func mockTitle(changes ChangeSet) string {
+	for _, line := range strings.Split(changes.Commits, "\n") {
+		if commitLinePrefix.MatchString(line) {
+			return commitLinePrefix.ReplaceAllString(line, "")
+		}
+	}
+	return "Update " + countNoun(len(changes.Files), "file")
+}
+
+// summarizeChanges lists the commit subjects and the most changed files of changes.
+func (mockProvider) summarizeChanges(changes ChangeSet) string {
+	var subjects []string
+	for _, line := range strings.Split(changes.Commits, "\n") {
+		if commitLinePrefix.MatchString(line) {
+			subjects = append(subjects, commitLinePrefix.ReplaceAllString(line, ""))
+		}
+	}
+	var added, deleted int
+	for _, stat := range changes.Files {
+		added += stat.Added
+		deleted += stat.Deleted
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "This change touches %s (+%d -%d)", countNoun(len(changes.Files), "file"), added, deleted)
+	if len(subjects) > 0 {
+		fmt.Fprintf(&b, " in %s", countNoun(len(subjects), "commit"))
+	}
+	b.WriteString(".\n")
+	if len(subjects) > 0 {
+		b.WriteString("\nChanges:\n")
+		for _, subject := range subjects {
+			b.WriteString("- " + subject + "\n")
+		}
+	}
+	if files := topFileStats(changes.Files, mockTopFiles); files != "" {
+		b.WriteString("\nMost changed files:\n" + files + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// countNoun formats a count with the singular or plural of noun, e.g. "1 file" or "3 files".
+func countNoun(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
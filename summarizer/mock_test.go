@@ -0,0 +1,28 @@
+package summarizer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMockSummary(t *testing.T) {
+	changes := ChangeSet{
+		Commits: "abc1234 - Add parser\ndef5678 - Fix lexer",
+		Diff:    "diff --git a/parser.go b/parser.go\n",
+		Files: []FileStat{
+			{Path: "parser.go", Added: 40, Deleted: 2},
+			{Path: "lexer.go", Added: 1, Deleted: 1},
+		},
+	}
+	want := "This change touches 2 files (+41 -3) in 2 commits.\n\n" +
+		"Changes:\n- Add parser\n- Fix lexer\n\n" +
+		"Most changed files:\n- parser.go (+40 -2)\n- lexer.go (+1 -1)"
+
+	summary, err := getSummary(context.Background(), mockProvider{}, changes, nil)
+	if err != nil {
+		t.Fatalf("getSummary() error = %v", err)
+	}
+	if summary != want {
+		t.Errorf("getSummary() = %q, want %q", summary, want)
+	}
+}
@@ -0,0 +1,222 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ollama models used for embeddings and compression. Both can be overridden with flags or env vars.
+var ollamaEmbeddingModel = "nomic-embed-text"
+var ollamaCompletionModel = "llama3.2"
+
+// skipEmbeddings leaves out the embeddings step. requireEmbeddings turns an unreachable Ollama
+// into an error instead of silently continuing without embeddings.
+var skipEmbeddings, requireEmbeddings bool
+
+// failFastOnOllama turns every failure of the Ollama compression and embeddings steps into an error.
+// Without it both steps are skipped with a warning: a failed compression sends the original content,
+// failed embeddings are left out of the prompt.
+var failFastOnOllama bool
+
+// embedSource selects what the embeddings are computed from: "compressed" embeds the compressed summary
+// after compression finishes, "raw" embeds the original content while compression runs concurrently.
+var embedSource = "compressed"
+
+// embedEncoding selects how the normalized embeddings are packed before base64 encoding in the prompt:
+// "json" encodes them as a JSON array of numbers, "float32" as little-endian float32 bytes.
+var embedEncoding = "json"
+
+// skipCompression sends the raw diff to the summary provider instead of an Ollama compressed summary.
+// This avoids the local model entirely but costs noticeably more input tokens on large diffs.
+var skipCompression bool
+
+// ollamaURL is the base URL of the Ollama server; setOllamaURL derives the API endpoints from it.
+var ollamaURL = "http://localhost:11434"
+
+// ollamaHealthTimeout bounds the startup health check so a stopped Ollama doesn't delay the run.
+const ollamaHealthTimeout = 2 * time.Second
+
+// setOllamaURL points the Ollama API endpoints at the server at base. A bare host:port,
+// as in OLLAMA_HOST, is taken to be plain HTTP.
+func setOllamaURL(base string) {
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	base = strings.TrimSuffix(base, "/")
+	ollamaURL = base
+	ollamaAPIURL = base + "/api/embeddings"
+	ollamaCompletionURL = base + "/api/generate"
+}
+
+// checkOllama checks that the Ollama server answers GET /api/tags within ollamaHealthTimeout.
+// Its errors wrap ErrOllamaUnavailable.
+func checkOllama(ctx context.Context) error {
+	if err := pingOllama(ctx); err != nil {
+		return fmt.Errorf("%w at %s: %v", ErrOllamaUnavailable, ollamaURL, err)
+	}
+	return nil
+}
+
+// pingOllama sends the GET /api/tags request of checkOllama.
+func pingOllama(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ollamaHealthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ollamaURL+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
+
+type OllamaEmbeddingRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+type OllamaEmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+type OllamaCompletionRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	System  string                 `json:"system,omitempty"`
+	Format  interface{}            `json:"format,omitempty"` // JSON schema the output must match
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+type OllamaCompletionResponse struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+}
+
+// ollamaModelError turns Ollama's "model not found" response into an actionable error.
+func ollamaModelError(err error, model string) error {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound && strings.Contains(string(statusErr.body), "not found") {
+		return fmt.Errorf("Ollama model %q not found, pull it with `ollama pull %s`", model, model)
+	}
+	return err
+}
+
+// getEmbeddings sends a request to the Ollama API to generate embeddings for the given text.
+// It returns the embeddings as a slice of float64 values and an error if any occurs.
+// Results are cached in memory so identical texts are only embedded once per run.
+func getEmbeddings(ctx context.Context, text string) ([]float64, error) {
+	if cached, ok := embeddingsCache.get(text); ok {
+		return cached, nil
+	}
+	defer metrics.track(phaseEmbeddings)()
+
+	requestBody, err := json.Marshal(OllamaEmbeddingRequest{
+		Model:  ollamaEmbeddingModel,
+		Prompt: text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	_, body, err := doWithRetry(ctx, "Ollama embeddings API", func() (*http.Request, error) {
+		return newJSONRequest(ctx, ollamaAPIURL, requestBody)
+	})
+	if err != nil {
+		return nil, ollamaModelError(err, ollamaEmbeddingModel)
+	}
+
+	embedding, err := decodeOllamaEmbeddingResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddingsCache.put(text, embedding)
+	return embedding, nil
+}
+
+// processEmbeddings calculates the magnitude of the embeddings, normalizes them, and converts them to a base64 string.
+// An empty or zero-magnitude vector can't be normalized and yields an empty string.
+func processEmbeddings(embeddings []float64) (string, error) {
+	// Calculate magnitude
+	var magnitude float64
+	for _, v := range embeddings {
+		magnitude += v * v
+	}
+	magnitude = math.Sqrt(magnitude)
+	if magnitude == 0 {
+		return "", nil
+	}
+
+	// Normalize embeddings
+	normalized := make([]float64, len(embeddings))
+	for i, v := range embeddings {
+		normalized[i] = v / magnitude
+	}
+
+	// Convert to base64 for compact representation
+	if embedEncoding == "float32" {
+		return base64.StdEncoding.EncodeToString(packFloat32(normalized)), nil
+	}
+	bytes, err := json.Marshal(normalized)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling embeddings: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
+
+// packFloat32 packs values as little-endian float32s, a quarter of the size of their JSON and
+// far cheaper to produce. The lost precision doesn't matter for normalized embeddings.
+func packFloat32(values []float64) []byte {
+	packed := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(packed[4*i:], math.Float32bits(float32(v)))
+	}
+	return packed
+}
+
+// compressLogs sends a request to the Ollama API to compress and summarize the given content.
+// It returns the compressed summary as a string and an error if any occurs.
+func compressLogs(ctx context.Context, content string) (string, error) {
+	prompt := fmt.Sprintf(`Compress and summarize the following git changes into a concise but informative format, 
+preserving the most important technical details:
+
+%s
+
+Compressed summary:`, content)
+	defer metrics.track(phaseCompression)()
+
+	requestBody, err := json.Marshal(OllamaCompletionRequest{
+		Model:  ollamaCompletionModel,
+		Prompt: prompt,
+		Stream: false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	_, body, err := doWithRetry(ctx, "Ollama completion API", func() (*http.Request, error) {
+		return newJSONRequest(ctx, ollamaCompletionURL, requestBody)
+	})
+	if err != nil {
+		return "", ollamaModelError(err, ollamaCompletionModel)
+	}
+
+	return decodeOllamaCompletionResponse(body)
+}
@@ -0,0 +1,192 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestGetEmbeddings(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"embedding":[0.5,1,2]}`, want: []float64{0.5, 1, 2}},
+		{name: "non-2xx status", status: http.StatusUnauthorized, body: `{"error":"unauthorized"}`, wantErr: true},
+		{name: "malformed JSON", status: http.StatusOK, body: `{"embedding":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &ollamaAPIURL, tt.status, tt.body)
+			embeddingsCache = newEmbeddingCache(defaultEmbeddingCacheSize)
+
+			got, err := getEmbeddings(context.Background(), "some text")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getEmbeddings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("getEmbeddings() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("getEmbeddings() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressLogs(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"response":"compressed","done":true}`, want: "compressed"},
+		{name: "non-2xx status", status: http.StatusBadRequest, body: `{"error":"bad request"}`, wantErr: true},
+		{name: "malformed JSON", status: http.StatusOK, body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &ollamaCompletionURL, tt.status, tt.body)
+
+			got, err := compressLogs(context.Background(), "diff")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compressLogs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("compressLogs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessEmbeddings(t *testing.T) {
+	encoded, err := processEmbeddings([]float64{3, 4})
+	if err != nil {
+		t.Fatalf("processEmbeddings() error = %v", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("processEmbeddings() returned invalid base64: %v", err)
+	}
+	var normalized []float64
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		t.Fatalf("processEmbeddings() returned invalid JSON: %v", err)
+	}
+
+	want := []float64{0.6, 0.8}
+	for i := range want {
+		if math.Abs(normalized[i]-want[i]) > 1e-9 {
+			t.Fatalf("processEmbeddings() = %v, want %v", normalized, want)
+		}
+	}
+}
+
+func TestProcessEmbeddingsZeroMagnitude(t *testing.T) {
+	for _, embeddings := range [][]float64{nil, {}, {0, 0, 0}} {
+		got, err := processEmbeddings(embeddings)
+		if err != nil || got != "" {
+			t.Errorf("processEmbeddings(%v) = %q, %v, want empty string", embeddings, got, err)
+		}
+	}
+}
+
+func TestProcessEmbeddingsFloat32(t *testing.T) {
+	original := embedEncoding
+	embedEncoding = "float32"
+	defer func() { embedEncoding = original }()
+
+	encoded, err := processEmbeddings([]float64{3, 4})
+	if err != nil {
+		t.Fatalf("processEmbeddings() error = %v", err)
+	}
+	packed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("processEmbeddings() returned invalid base64: %v", err)
+	}
+	if len(packed) != 8 {
+		t.Fatalf("processEmbeddings() packed %d bytes, want 8", len(packed))
+	}
+	want := []float32{0.6, 0.8}
+	for i := range want {
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(packed[4*i:])); got != want[i] {
+			t.Errorf("value %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+// BenchmarkProcessEmbeddings measures both encodings on a vector the size of nomic-embed-text's.
+func BenchmarkProcessEmbeddings(b *testing.B) {
+	embeddings := make([]float64, 768)
+	for i := range embeddings {
+		embeddings[i] = math.Sin(float64(i))
+	}
+	original := embedEncoding
+	defer func() { embedEncoding = original }()
+
+	for _, encoding := range []string{"json", "float32"} {
+		b.Run(encoding, func(b *testing.B) {
+			embedEncoding = encoding
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := processEmbeddings(embeddings); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFailFastOnOllama(t *testing.T) {
+	originals := []bool{failFastOnOllama, skipCompression, skipEmbeddings, noDiskCache}
+	defer func() {
+		failFastOnOllama, skipCompression, skipEmbeddings, noDiskCache = originals[0], originals[1], originals[2], originals[3]
+	}()
+	noDiskCache = true
+	changes := ChangeSet{Commits: "abc1234 - Fix", Diff: "diff --git a/a.go b/a.go\n+x", Overview: "a.go | 1 +"}
+
+	tests := []struct {
+		name        string
+		compression bool // the compression step runs and fails, otherwise the embeddings step fails
+	}{
+		{name: "compression", compression: true},
+		{name: "embeddings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipCompression, skipEmbeddings = !tt.compression, tt.compression
+			if tt.compression {
+				fakeAPI(t, &ollamaCompletionURL, http.StatusInternalServerError, `{"error":"model crashed"}`)
+			} else {
+				fakeAPI(t, &ollamaAPIURL, http.StatusInternalServerError, `{"error":"model crashed"}`)
+			}
+
+			failFastOnOllama = false
+			c, err := compressChanges(context.Background(), changes)
+			if err != nil {
+				t.Fatalf("compressChanges() error = %v, want the step skipped", err)
+			}
+			if tt.compression && !c.Raw || !tt.compression && c.Embeddings != nil {
+				t.Errorf("compressChanges() = %+v, want the %s left out", c, tt.name)
+			}
+
+			failFastOnOllama = true
+			if _, err := compressChanges(context.Background(), changes); err == nil {
+				t.Errorf("compressChanges() with -fail-fast-on-ollama succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// openAIProvider generates summaries with the OpenAI chat completions API, or with an
+// Azure OpenAI deployment, which uses the same request and response shapes.
+type openAIProvider struct {
+	api    string // API name used in messages
+	url    string
+	apiKey string
+	model  string
+	azure  bool // authenticate with the api-key header instead of a bearer token
+}
+
+// Summarize sends the prompt to the chat completions API and returns the generated text.
+func (p *openAIProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	return p.complete(ctx, prompt, nil)
+}
+
+// SummarizeStructured sends the prompt with a json_schema response format, so the reply is a JSON
+// object matching structuredSchema.
+func (p *openAIProvider) SummarizeStructured(ctx context.Context, prompt string) (string, error) {
+	return p.complete(ctx, prompt, map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   structuredSchemaName,
+			"schema": structuredSchema,
+			"strict": true,
+		},
+	})
+}
+
+// complete sends the prompt to the chat completions API, with the response format if one is given,
+// and returns the generated text.
+func (p *openAIProvider) complete(ctx context.Context, prompt string, responseFormat map[string]interface{}) (string, error) {
+	var messages []map[string]string
+	if systemPrompt != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": systemPrompt})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": prompt})
+
+	request := map[string]interface{}{
+		"model":    p.model,
+		"messages": messages,
+	}
+	addSamplingParams(request, "temperature", "top_p")
+	if responseFormat != nil {
+		request["response_format"] = responseFormat
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	_, body, err := doWithRetry(ctx, p.api, func() (*http.Request, error) {
+		req, err := newJSONRequest(ctx, p.url, requestBody)
+		if err != nil {
+			return nil, err
+		}
+		if p.azure {
+			req.Header.Set("api-key", p.apiKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+		setCustomHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	recordResponseUsage(body)
+	return decodeOpenAIResponse(body)
+}
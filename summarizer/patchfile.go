@@ -0,0 +1,64 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// patchSubjectPrefix matches the "[PATCH n/m]" tag git format-patch puts in front of subjects.
+var patchSubjectPrefix = regexp.MustCompile(`^\[PATCH[^\]]*\]\s*`)
+
+// readPatchFile reads a unified diff or git format-patch file and returns its content.
+func readPatchFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading patch file: %v", err)
+	}
+	patch := strings.TrimSpace(sanitizeText(string(content)))
+	if !looksLikeDiff(patch) {
+		return "", fmt.Errorf("%s doesn't look like a unified diff", path)
+	}
+	return patch, nil
+}
+
+// looksLikeDiff reports whether text contains a git file header or a ---/+++ file header pair.
+func looksLikeDiff(text string) bool {
+	if strings.HasPrefix(text, "diff --git ") || strings.Contains(text, "\ndiff --git ") {
+		return true
+	}
+	return strings.Contains(text, "\n+++ ") && (strings.HasPrefix(text, "--- ") || strings.Contains(text, "\n--- "))
+}
+
+// parsePatchCommits lists the commits of git format-patch output as "hash - subject" lines,
+// reading the "From <hash>" separators and the Subject headers including their continuation lines.
+// It returns an error when the patch has no commit metadata, e.g. for a plain git diff.
+func parsePatchCommits(patch string) (string, error) {
+	var commits []string
+	var hash, subject string
+	inSubject := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "From ") && len(strings.Fields(line)) > 2:
+			hash = strings.Fields(line)[1]
+			inSubject = false
+		case strings.HasPrefix(line, "Subject: "):
+			subject = strings.TrimPrefix(line, "Subject: ")
+			inSubject = true
+		case inSubject && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			subject += " " + strings.TrimSpace(line)
+		case inSubject:
+			inSubject = false
+			if len(hash) > 7 {
+				hash = hash[:7]
+			}
+			commits = append(commits, fmt.Sprintf("%s - %s", hash, patchSubjectPrefix.ReplaceAllString(subject, "")))
+		}
+	}
+	if len(commits) == 0 {
+		return "", errors.New("no commit metadata in patch")
+	}
+	return strings.Join(commits, "\n"), nil
+}
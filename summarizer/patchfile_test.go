@@ -0,0 +1,84 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePatchCommits(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "format-patch with continued subject",
+			patch: formatPatch,
+			want:  "1234567 - Add parser for the config format\nabcdef1 - Fix lexer",
+		},
+		{
+			name:  "subject without PATCH prefix",
+			patch: "From 1234567890abcdef Mon Sep 17 00:00:00 2001\nSubject: Fix lexer\n\nbody\n",
+			want:  "1234567 - Fix lexer",
+		},
+		{
+			name:    "plain diff",
+			patch:   "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePatchCommits(tt.patch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePatchCommits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parsePatchCommits() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPatchFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{name: "format-patch", content: formatPatch, want: strings.TrimSpace(formatPatch)},
+		{name: "unified diff without git headers", content: "--- a.go\n+++ a.go\n@@ -1 +1 @@\n-a\n+b\n", want: "--- a.go\n+++ a.go\n@@ -1 +1 @@\n-a\n+b"},
+		{name: "not a diff", content: "just some notes\n", wantErr: "doesn't look like a unified diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "changes.patch")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readPatchFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readPatchFile() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPatchFile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readPatchFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readPatchFile(filepath.Join(t.TempDir(), "missing.patch")); err == nil {
+		t.Error("readPatchFile() of a missing file succeeded, want an error")
+	}
+}
@@ -0,0 +1,126 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// perCommitConcurrency is the number of commits -per-commit summarizes at the same time.
+const perCommitConcurrency = 3
+
+// perCommitDiffChars caps the diff of each commit sent for its one-line summary.
+const perCommitDiffChars = 8000
+
+// perCommitPrompt asks for the one-line summary of a single commit, given its subject and diff.
+const perCommitPrompt = `Summarize what this git commit changes in one line of at most 20 words, for a reviewer scanning the commits of a pull request. Reply with the line only, without a trailing period.
+
+Subject: %s
+
+Diff:
+%s`
+
+// commitDiff returns the diff of a single commit for its -per-commit summary, with binary content
+// collapsed, secrets redacted and the size capped at perCommitDiffChars.
+func commitDiff(hash string, extraDiffArgs, pathArgs []string) (string, error) {
+	args := append(append([]string{"show", "--no-ext-diff", "--format="}, extraDiffArgs...), hash)
+	diff, err := getCommandOutput("git", append(args, pathArgs...)...)
+	if err != nil {
+		return "", err
+	}
+	diff, _ = collapseBinaryDiffs(diff)
+	changes, _ := redactChanges(ChangeSet{Diff: diff})
+	return truncateDiff(changes.Diff, perCommitDiffChars), nil
+}
+
+// summarizeCommits generates a one-line summary of every commit, at most perCommitConcurrency at a
+// time, and returns them by commit hash. A commit whose diff or summary fails is left out with a
+// warning, so it keeps its subject in the commit list.
+func summarizeCommits(ctx context.Context, provider SummaryProvider, commits []commit, extraDiffArgs, pathArgs []string) map[string]string {
+	defer status.clear()
+	// The diffs are gathered first, git runs quickly compared to the summaries
+	diffs := make([]string, len(commits))
+	for i, c := range commits {
+		diff, err := commitDiff(c.Hash, extraDiffArgs, pathArgs)
+		if err != nil {
+			warnf("Warning: could not get the diff of commit %s: %v", c.Hash, err)
+			continue
+		}
+		diffs[i] = diff
+	}
+
+	status.set(fmt.Sprintf("Summarizing %s", countNoun(len(commits), "commit")))
+	notes := make([]string, len(commits))
+	slots := make(chan struct{}, perCommitConcurrency)
+	var wg sync.WaitGroup
+	for i, c := range commits {
+		if diffs[i] == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			note, err := summarizeCommit(ctx, provider, c, diffs[i])
+			if err != nil {
+				if ctx.Err() == nil {
+					warnf("Warning: could not summarize commit %s: %v", c.Hash, err)
+				}
+				return
+			}
+			notes[i] = note
+		}()
+	}
+	wg.Wait()
+
+	byHash := map[string]string{}
+	for i, c := range commits {
+		if notes[i] != "" {
+			byHash[c.Hash] = notes[i]
+		}
+	}
+	return byHash
+}
+
+// summarizeCommit returns the one-line summary of a single commit.
+func summarizeCommit(ctx context.Context, provider SummaryProvider, c commit, diff string) (string, error) {
+	if offline, ok := provider.(offlineProvider); ok {
+		summary := offline.summarizeChanges(ChangeSet{Diff: diff, Files: diffFileStats(diff)})
+		line, _, _ := strings.Cut(summary, "\n")
+		return strings.TrimSuffix(line, "."), nil
+	}
+	prompt := fmt.Sprintf(perCommitPrompt, c.Subject, diff)
+	done := metrics.track(phaseSummary)
+	summary, err := provider.Summarize(ctx, prompt)
+	done()
+	if err != nil {
+		return "", err
+	}
+	metrics.addEstimate(prompt, summary)
+	// Models sometimes add a second line or a bullet despite the instructions
+	line, _, _ := strings.Cut(strings.TrimSpace(summary), "\n")
+	return strings.TrimSuffix(strings.TrimLeft(line, "-* "), "."), nil
+}
+
+// annotateCommits turns the "hash - subject" lines of a git log commit list that have a summary
+// into "- hash — summary" bullets. Other lines, like the "(+N earlier commits)" note, are kept.
+func annotateCommits(log string, notes map[string]string) string {
+	lines := strings.Split(log, "\n")
+	for i, line := range lines {
+		hash, _, _ := strings.Cut(line, " - ")
+		if note, ok := notes[hash]; ok {
+			lines[i] = fmt.Sprintf("- %s — %s", hash, note)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withCommitSummaries sets the -per-commit summaries of commits for the JSON output.
+func withCommitSummaries(commits []commit, notes map[string]string) []commit {
+	for i := range commits {
+		commits[i].Summary = notes[commits[i].Hash]
+	}
+	return commits
+}
@@ -0,0 +1,43 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// subjectEcho summarizes a commit with the subject line of the per-commit prompt, failing for "boom".
+type subjectEcho struct{}
+
+func (subjectEcho) Summarize(ctx context.Context, prompt string) (string, error) {
+	_, rest, _ := strings.Cut(prompt, "Subject: ")
+	subject, _, _ := strings.Cut(rest, "\n")
+	if subject == "boom" {
+		return "", errors.New("rate limited")
+	}
+	return "- Summary of " + subject + ".\nA second line", nil
+}
+
+func TestSummarizeCommits(t *testing.T) {
+	fakeCommands(t, map[string]string{
+		"git show --no-ext-diff --format= abc1234 -- :(top)src": "diff --git a/src/a.go b/src/a.go\n+one",
+		"git show --no-ext-diff --format= def5678 -- :(top)src": "diff --git a/src/b.go b/src/b.go\n+two",
+	})
+	commits := []commit{
+		{Hash: "abc1234", Subject: "Add parser"},
+		{Hash: "def5678", Subject: "boom"},
+		{Hash: "0badbad", Subject: "No diff"},
+	}
+
+	notes := summarizeCommits(context.Background(), subjectEcho{}, commits, nil, []string{"--", ":(top)src"})
+	if len(notes) != 1 || notes["abc1234"] != "Summary of Add parser" {
+		t.Fatalf("summarizeCommits() = %q, want only abc1234 summarized", notes)
+	}
+
+	log := "abc1234 - Add parser\ndef5678 - boom\n(+2 earlier commits)"
+	want := "- abc1234 — Summary of Add parser\ndef5678 - boom\n(+2 earlier commits)"
+	if got := markdownCommits(annotateCommits(log, notes)); got != want {
+		t.Errorf("annotated commit list = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package summarizer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// platforms lists the code hosting platforms -platform accepts.
+var platforms = []string{"github", "gitlab", "bitbucket", "gitea"}
+
+// prPlatform is the platform the PR markdown is written for.
+var prPlatform = "github"
+
+// gitlabMRTemplate is the built-in layout of a GitLab merge request description.
+const gitlabMRTemplate = `# Merge Request Summary
+
+## Branch: {{.Branch}}{{if .Since}} (commits since {{.Since}}){{end}}
+
+## Commits:
+{{.Commits}}
+{{if .Merges}}
+_{{.Merges}}_
+{{end}}{{if .Issues}}
+## Related Issues:
+{{.Issues}}
+{{end}}
+## Changes Overview:
+{{.Overview}}
+
+# Summary:
+{{.Summary}}
+
+## Detailed Description:
+<!-- Please provide a detailed description of the changes in this MR -->
+`
+
+// bitbucketPRTemplate is the built-in layout of a Bitbucket pull request description. Bitbucket
+// shows HTML comments literally, so the description prompt is plain italic text.
+const bitbucketPRTemplate = `# Pull Request Summary
+
+## Branch: {{.Branch}}{{if .Since}} (commits since {{.Since}}){{end}}
+
+## Commits:
+{{.Commits}}
+{{if .Merges}}
+_{{.Merges}}_
+{{end}}{{if .Issues}}
+## Related Issues:
+{{.Issues}}
+{{end}}
+## Changes Overview:
+{{.Overview}}
+
+# Summary:
+{{.Summary}}
+
+## Detailed Description:
+_Please provide a detailed description of the changes in this PR._
+`
+
+// platformPRTemplates are the built-in PR templates of each platform.
+var platformPRTemplates = map[string]string{
+	"github":    defaultPRTemplate,
+	"gitlab":    gitlabMRTemplate,
+	"bitbucket": bitbucketPRTemplate,
+	"gitea":     defaultPRTemplate,
+}
+
+// platformTemplateCandidates are the repository PR template files of each platform, see prTemplateCandidates.
+var platformTemplateCandidates = map[string][]string{
+	"github": prTemplateCandidates,
+	"gitlab": {".gitlab/merge_request_templates/Default.md"},
+	"gitea":  {".gitea/pull_request_template.md", ".gitea/PULL_REQUEST_TEMPLATE.md", ".github/pull_request_template.md"},
+}
+
+// validatePlatform checks that platform is one of platforms.
+func validatePlatform(platform string) error {
+	if !slices.Contains(platforms, platform) {
+		return fmt.Errorf("unknown platform %q (expected %s)", platform, strings.Join(platforms, ", "))
+	}
+	return nil
+}
+
+// closingReference returns the line that links an issue reference in a description on platform, using
+// the keyword that closes the issue when the PR is merged. Only "#123" style references of the
+// platform's own issue tracker can be closed; others, such as Jira keys, are listed as they are.
+func closingReference(platform, ref string) string {
+	if !strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	if platform == "bitbucket" {
+		return "Closes issue " + ref
+	}
+	return "Closes " + ref
+}
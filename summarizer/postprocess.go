@@ -0,0 +1,50 @@
+package summarizer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// shellCommand returns a command running command with the system shell, sh -c or cmd /C on Windows.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// postProcess pipes text through the -post-process command and returns what it prints, without
+// trailing newlines. A command exiting with an error fails with its stderr.
+func postProcess(command, text string) (string, error) {
+	cmd := shellCommand(command)
+	cmd.Stdin = strings.NewReader(text + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%q failed: %v: %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("error running %q: %v", command, err)
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// applyPostProcess returns text piped through the -post-process command, if there is one. When
+// the command fails it returns text unchanged along with the error, so the result is still emitted.
+func applyPostProcess(o *options, text string) (string, error) {
+	if o.postProcess == "" {
+		return text, nil
+	}
+	processed, err := postProcess(o.postProcess, text)
+	if err != nil {
+		return text, fail(exitFailure, "Error: -post-process: %v", err)
+	}
+	logf("Post-processed the result with %q", o.postProcess)
+	return processed, nil
+}
@@ -0,0 +1,55 @@
+package summarizer
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{name: "prepends a line", command: `sed '1i\
+PROJ-1 #comment'`, want: "PROJ-1 #comment\nsummary\nsecond line"},
+		{name: "trailing newlines trimmed", command: "cat; echo; echo", want: "summary\nsecond line"},
+		{name: "non-zero exit", command: "echo misspelled >&2; exit 2", wantErr: "exit status 2: misspelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := postProcess(tt.command, "summary\nsecond line")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("postProcess() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("postProcess() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("postProcess() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostProcessKeepsTextOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	got, err := applyPostProcess(&options{postProcess: "exit 1"}, "summary")
+	if err == nil || exitCode(err) != exitFailure {
+		t.Fatalf("applyPostProcess() error = %v, want an exitFailure error", err)
+	}
+	if got != "summary" {
+		t.Fatalf("applyPostProcess() = %q, want the text unchanged", got)
+	}
+}
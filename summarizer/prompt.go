@@ -0,0 +1,132 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// repoPromptPath is the location, relative to the repository root, of a committed prompt override.
+const repoPromptPath = ".prgpt/prompt.md"
+
+// personaSystemPrompt is the system prompt of modes whose prompt template carries its own instructions.
+const personaSystemPrompt = "You are an experienced software engineer who writes clear, accurate descriptions of Git changes."
+
+// defaultSystemPrompt holds the instructions sent as the system prompt alongside the default prompt template.
+const defaultSystemPrompt = personaSystemPrompt + " Based on the Git changes in the user message, provide a concise summary of the modifications."
+
+// systemPrompt is sent to the summary provider as its system prompt, separately from the rendered
+// prompt template, which only carries the changes. An empty system prompt is left out of the request.
+var systemPrompt = defaultSystemPrompt
+
+// useTemplateInstructions switches from the default system prompt to personaSystemPrompt for prompt
+// templates that carry their own instructions. A system prompt set with -system-prompt is kept.
+func useTemplateInstructions() {
+	if systemPrompt == defaultSystemPrompt {
+		systemPrompt = personaSystemPrompt
+	}
+}
+
+// defaultPromptTemplate is the built-in summarization prompt. The instructions are in defaultSystemPrompt.
+const defaultPromptTemplate = `Here are the Git changes{{if .Embeddings}} with their semantic embeddings{{end}}:
+{{if .Embeddings}}
+Embeddings: {{.Embeddings}}
+{{end}}{{if .Compressed}}
+Compressed Changes:
+{{.Compressed}}
+{{end}}{{if .FileGroups}}
+Related files, grouped by the similarity of their changes:
+{{.FileGroups}}
+{{end}}
+Original Content Summary:
+{{.Diff}}
+{{if .TimeRange}}
+These are all changes made {{.TimeRange}}.
+{{end}}{{if .Issues}}
+The changes relate to these issues: {{.Issues}}. Reference them in the summary where they apply.
+{{end}}`
+
+// promptData holds the values available to the summarization prompt template.
+type promptData struct {
+	Diff       string // detailed diff and stat overview
+	Commits    string // commit list, one "hash - subject" per line
+	Embeddings string // base64 encoded normalized embeddings, empty when unavailable
+	Compressed string // compressed summary of the changes, empty when compression is skipped
+	TimeRange  string // time range of the changes with -since, e.g. "since 2 weeks ago"
+	FileStats  string // the most changed files with their added and deleted line counts, one per line
+	FileGroups string // changed files grouped by the similarity of their embeddings, one group per line
+	Issues     string // comma separated issue references found with -link-issues
+}
+
+// cacheBreakpointMarker is emitted by the {{cacheBreakpoint}} template function. With -prompt-cache the
+// prompt text before it is sent as a separately cached block, so it should only hold static instructions.
+const cacheBreakpointMarker = "\x00cache-breakpoint\x00"
+
+// promptFuncs are the functions available to summarization prompt templates.
+var promptFuncs = template.FuncMap{
+	"cacheBreakpoint": func() string { return cacheBreakpointMarker },
+}
+
+// newPromptTemplate parses a summarization prompt template with the prompt functions available.
+func newPromptTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(promptFuncs).Parse(text)
+}
+
+// splitCacheBreakpoint splits a rendered prompt at its cache breakpoint, if it has one.
+func splitCacheBreakpoint(prompt string) (prefix, rest string, ok bool) {
+	return strings.Cut(prompt, cacheBreakpointMarker)
+}
+
+// stripCacheBreakpoint removes the cache breakpoint marker from a rendered prompt.
+func stripCacheBreakpoint(prompt string) string {
+	return strings.Replace(prompt, cacheBreakpointMarker, "", 1)
+}
+
+// promptTemplate is the summarization prompt template used for this run.
+var promptTemplate = template.Must(newPromptTemplate("prompt", defaultPromptTemplate))
+
+// loadPromptTemplate returns the summarization prompt template for the repository at repoRoot.
+// Custom templates are expected to hold their own instructions, see useTemplateInstructions.
+// Sources are checked in order of precedence, highest first:
+//  1. the -prompt-template flag (or prompt_template config key)
+//  2. .prgpt/prompt.md committed at the repository root
+//  3. the built-in default prompt
+func loadPromptTemplate(repoRoot, templatePath string) (*template.Template, error) {
+	if templatePath != "" {
+		useTemplateInstructions()
+		return parsePromptFile(templatePath)
+	}
+
+	tmpl, err := parsePromptFile(filepath.Join(repoRoot, repoPromptPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return newPromptTemplate("prompt", defaultPromptTemplate)
+	}
+	useTemplateInstructions()
+	return tmpl, err
+}
+
+// parsePromptFile reads and parses a prompt template file.
+func parsePromptFile(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := newPromptTemplate(filepath.Base(path), string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return tmpl, nil
+}
+
+// renderPrompt executes the prompt template with the given data.
+func renderPrompt(tmpl *template.Template, data promptData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt template: %v", err)
+	}
+	return b.String(), nil
+}
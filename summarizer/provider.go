@@ -0,0 +1,145 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SummaryProvider generates a summary from a fully rendered prompt.
+type SummaryProvider interface {
+	Summarize(ctx context.Context, prompt string) (string, error)
+}
+
+// StreamingProvider is implemented by providers that can write the summary to w as it is generated.
+// The complete summary is returned once the stream has finished.
+type StreamingProvider interface {
+	SummaryProvider
+	SummarizeStream(ctx context.Context, prompt string, w io.Writer) (string, error)
+}
+
+// chatMessage is a turn of a conversation with a summary provider; Role is "user" or "assistant".
+type chatMessage struct {
+	Role    string
+	Content string
+}
+
+// ConversationProvider is implemented by providers that can continue a conversation about the summary.
+// The first message is the prompt, and the reply to the last message is returned.
+type ConversationProvider interface {
+	SummaryProvider
+	Converse(ctx context.Context, messages []chatMessage) (string, error)
+}
+
+// StructuredProvider is implemented by providers that can hold the reply to the structuredSchema
+// JSON schema, e.g. with tool use or a response format. It returns the JSON object as text.
+type StructuredProvider interface {
+	SummaryProvider
+	SummarizeStructured(ctx context.Context, prompt string) (string, error)
+}
+
+// newProvider returns the summary provider with the given name.
+func newProvider(name string) (SummaryProvider, error) {
+	if err := resolveAPIKey(name); err != nil {
+		return nil, err
+	}
+	switch name {
+	case "anthropic":
+		return &anthropicProvider{
+			apiKey:    anthropicAPIKey,
+			model:     anthropicModel,
+			maxTokens: anthropicMaxTokens,
+		}, nil
+	case "openai":
+		return &openAIProvider{
+			api:    "OpenAI API",
+			url:    openAIAPIURL,
+			apiKey: openAIAPIKey,
+			model:  openAIModel,
+		}, nil
+	case "azure":
+		chatURL, err := azureChatURL(azureEndpoint, azureDeployment, azureAPIVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &openAIProvider{
+			api:    "Azure OpenAI API",
+			url:    chatURL,
+			apiKey: azureAPIKey,
+			model:  azureDeployment,
+			azure:  true,
+		}, nil
+	case "gemini":
+		return &geminiProvider{
+			apiKey: geminiAPIKey,
+			model:  geminiModel,
+		}, nil
+	case "bedrock":
+		if bedrockRegion == "" {
+			return nil, errors.New("-region (or AWS_REGION) is required with -provider bedrock")
+		}
+		// Missing credentials are reported by checkCredentials, so -dry-run works without them
+		creds, _ := loadAWSCredentials()
+		return &bedrockProvider{
+			region:    bedrockRegion,
+			creds:     creds,
+			model:     bedrockModel,
+			maxTokens: anthropicMaxTokens,
+		}, nil
+	case "ollama":
+		return &ollamaProvider{
+			model:     summaryModel(),
+			maxTokens: anthropicMaxTokens,
+		}, nil
+	case "mock":
+		return mockProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (expected anthropic, openai, azure, gemini, bedrock, ollama or mock)", name)
+	}
+}
+
+// checkCredentials reports a missing API key for the named provider.
+func checkCredentials(name string) error {
+	var env, key string
+	switch name {
+	case "anthropic":
+		env, key = "ANTHROPIC_API_KEY", anthropicAPIKey
+	case "openai":
+		env, key = "OPENAI_API_KEY", openAIAPIKey
+	case "azure":
+		env, key = "AZURE_OPENAI_KEY", azureAPIKey
+	case "gemini":
+		env, key = "GEMINI_API_KEY", geminiAPIKey
+	case "bedrock":
+		_, err := loadAWSCredentials()
+		return err
+	default:
+		return nil
+	}
+	if key == "" {
+		return fmt.Errorf("%s is not set", env)
+	}
+	return nil
+}
+
+// providerModel returns the model the named provider uses, for diagnostics.
+func providerModel(name string) string {
+	switch name {
+	case "anthropic":
+		return anthropicModel
+	case "openai":
+		return openAIModel
+	case "azure":
+		return azureDeployment
+	case "gemini":
+		return geminiModel
+	case "bedrock":
+		return bedrockModel
+	case "ollama":
+		return summaryModel()
+	case "mock":
+		return "mock"
+	}
+	return ""
+}
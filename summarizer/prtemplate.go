@@ -0,0 +1,191 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// defaultPRTemplate is the built-in layout of the generated PR markdown.
+const defaultPRTemplate = `# Pull Request Summary
+
+## Branch: {{.Branch}}{{if .Since}} (commits since {{.Since}}){{end}}
+
+## Commits:
+{{.Commits}}
+{{if .Merges}}
+_{{.Merges}}_
+{{end}}{{if .Issues}}
+## Related Issues:
+{{.Issues}}
+{{end}}
+## Changes Overview:
+{{.Overview}}
+
+# Summary:
+{{.Summary}}
+
+## Detailed Description:
+<!-- Please provide a detailed description of the changes in this PR -->
+`
+
+// prTemplateCandidates are the repository files checked for a PR template when -pr-template isn't given.
+// They are only used when they contain template actions, so plain GitHub PR templates are left alone.
+var prTemplateCandidates = []string{
+	".github/PULL_REQUEST_TEMPLATE.md",
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE",
+}
+
+// summaryPlaceholder marks where the summary goes when the template is printed around a streamed summary.
+const summaryPlaceholder = "\x00summary\x00"
+
+// PRData holds the values available to the PR markdown template.
+type PRData struct {
+	Branch   string
+	Since    string // git date spec of -since, empty when comparing against a base branch
+	Commits  string
+	Merges   string // note on the merge commits in the range, empty when there are none
+	Issues   string // markdown list of the issue references found with -link-issues
+	Overview string
+	Title    string // title generated with -structured, empty otherwise
+	Summary  string
+	Vars     map[string]string // -var values, e.g. {{.Vars.jira}}
+}
+
+// prTemplate is the PR markdown template used for this run.
+var prTemplate = template.Must(template.New("pr").Parse(defaultPRTemplate))
+
+// loadPRTemplate returns the PR markdown template: the -pr-template file if given, otherwise a
+// PR template of the -platform in the repository that uses template actions, otherwise the
+// built-in layout of the platform.
+func loadPRTemplate(repoRoot, templatePath string) (*template.Template, error) {
+	if templatePath != "" {
+		return parsePRTemplateFile(templatePath)
+	}
+
+	for _, candidate := range platformTemplateCandidates[prPlatform] {
+		path := filepath.Join(repoRoot, candidate)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		if !strings.Contains(string(content), "{{") {
+			logf("Ignoring %s, it has no template fields", path)
+			continue
+		}
+		logf("Using PR template %s", path)
+		return parsePRTemplateFile(path)
+	}
+
+	return template.New("pr").Parse(platformPRTemplates[prPlatform])
+}
+
+// parsePRTemplateFile reads and parses a PR markdown template file.
+func parsePRTemplateFile(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vars that aren't set render empty, so a team template works without passing every -var
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=zero").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+
+	// Catch references to unknown fields now rather than after the summary was generated
+	if err := tmpl.Execute(io.Discard, PRData{}); err != nil {
+		return nil, fmt.Errorf("error in %s: %v", path, err)
+	}
+	return tmpl, nil
+}
+
+// commitLinePrefix matches the "hash - " start of a commit list line, or the "- hash — " start of
+// one annotated with its -per-commit summary.
+var commitLinePrefix = regexp.MustCompile(`^(- )?[0-9a-f]{4,40} [-—] `)
+
+// markdownSpecial lists the characters escaped in commit subjects so they render literally.
+const markdownSpecial = "\\`*_[]<>|~&"
+
+// escapeMarkdown backslash-escapes markdown and HTML syntax in text. A "#", "+" or "-" is only
+// escaped at the start, where it would begin a heading or list, so "#123" issue links keep working.
+func escapeMarkdown(text string) string {
+	var b strings.Builder
+	for i, r := range text {
+		if strings.ContainsRune(markdownSpecial, r) || i == 0 && strings.ContainsRune("#+-", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markdownCommits prepares a commit list for the PR markdown: subjects are escaped, and lines that don't
+// start a "hash - subject" entry, e.g. from a multi-line custom --pretty format, are joined onto the entry
+// before them. Parenthesized notes such as "(+3 earlier commits)" stay on their own line.
+func markdownCommits(log string) string {
+	var entries []string
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case len(entries) > 0 && !commitLinePrefix.MatchString(line) && !(strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")")):
+			entries[len(entries)-1] += " " + line
+		default:
+			entries = append(entries, line)
+		}
+	}
+
+	for i, entry := range entries {
+		if prefix := commitLinePrefix.FindString(entry); prefix != "" {
+			entries[i] = prefix + escapeMarkdown(entry[len(prefix):])
+		} else {
+			entries[i] = escapeMarkdown(entry)
+		}
+	}
+	return strings.Join(entries, "\n")
+}
+
+// renderPRSummary renders the final PR markdown. Commit subjects are escaped, see markdownCommits.
+func renderPRSummary(data PRData) (string, error) {
+	data.Commits = markdownCommits(data.Commits)
+	var b strings.Builder
+	if err := prTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering PR template: %v", err)
+	}
+	return b.String(), nil
+}
+
+// jsonResult is the output of -format json.
+type jsonResult struct {
+	Branch       string             `json:"branch"`
+	BaseBranch   string             `json:"baseBranch"`
+	Since        string             `json:"since,omitempty"`
+	Commits      []commit           `json:"commits"`
+	StatOverview string             `json:"statOverview"`
+	Files        []FileStat         `json:"files"`
+	Issues       []string           `json:"issues,omitempty"`
+	Summary      string             `json:"summary"`
+	Structured   *StructuredSummary `json:"structured,omitempty"`
+	Model        string             `json:"model"`
+}
+
+// renderJSON renders the result as indented JSON.
+func renderJSON(result jsonResult) (string, error) {
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling JSON output: %v", err)
+	}
+	return string(out), nil
+}
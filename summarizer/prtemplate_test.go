@@ -0,0 +1,67 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownCommits(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want string
+	}{
+		{name: "plain subject", log: "abc1234 - Add parser", want: "abc1234 - Add parser"},
+		{name: "backticks and emphasis", log: "abc1234 - Fix `go vet` *warnings* in __init__", want: "abc1234 - Fix \\`go vet\\` \\*warnings\\* in \\_\\_init\\_\\_"},
+		{name: "HTML", log: "abc1234 - Render <script>alert(1)</script> & co", want: "abc1234 - Render \\<script\\>alert(1)\\</script\\> \\& co"},
+		{name: "heading at start", log: "abc1234 - # not a heading", want: "abc1234 - \\# not a heading"},
+		{name: "issue reference", log: "abc1234 - Fix crash (#123)", want: "abc1234 - Fix crash (#123)"},
+		{name: "link syntax", log: "abc1234 - See [docs](http://example.com)", want: "abc1234 - See \\[docs\\](http://example.com)"},
+		{name: "table pipes", log: "abc1234 - a | b", want: "abc1234 - a \\| b"},
+		{name: "multi-line message", log: "abc1234 - First line\nsecond line\n\ndef5678 - Next", want: "abc1234 - First line second line\ndef5678 - Next"},
+		{name: "earlier commits note", log: "abc1234 - Latest\n(+2 earlier commits)", want: "abc1234 - Latest\n(+2 earlier commits)"},
+		{name: "placeholder", log: "(uncommitted changes staged in the index)", want: "(uncommitted changes staged in the index)"},
+		{name: "line without hash", log: "- list item", want: "\\- list item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownCommits(tt.log); got != tt.want {
+				t.Errorf("markdownCommits() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRTemplateVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pr.md")
+	if err := os.WriteFile(path, []byte("[{{.Vars.jira}}] env={{.Vars.env}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := parsePRTemplateFile(path)
+	if err != nil {
+		t.Fatalf("parsePRTemplateFile() error = %v", err)
+	}
+
+	vars := keyValueFlag{}
+	for _, pair := range []string{"jira=PROJ", "jira=OPS"} {
+		if err := vars.Set(pair); err != nil {
+			t.Fatalf("Set(%q) error = %v", pair, err)
+		}
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, PRData{Vars: vars}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := out.String(), "[OPS] env="; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+
+	for _, pair := range []string{"jira", "=PROJ"} {
+		if err := vars.Set(pair); err == nil {
+			t.Errorf("Set(%q) succeeded, want an error", pair)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package summarizer
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// redactSecrets replaces likely secrets in the diff before it is sent anywhere. On by default.
+var redactSecrets = true
+
+// redactedPlaceholder replaces every detected secret.
+const redactedPlaceholder = "***REDACTED***"
+
+// secretPatterns match well-known secret formats. Patterns with capture groups only redact the
+// last group, keeping e.g. the variable name of an assignment readable. The assignment pattern
+// captures the name first, so settings such as MAX_TOKENS are left alone (see isSettingAssignment).
+var secretPatterns = []*regexp.Regexp{
+	// Private key blocks, including the diff markers of their lines
+	regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`),
+	// AWS access key IDs
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// JSON web tokens
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+	// GitHub, Slack and OpenAI/Anthropic style API tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bsk-(?:ant-)?[A-Za-z0-9_-]{20,}`),
+	// .env style assignments of keys, secrets, tokens and passwords
+	regexp.MustCompile(`(?m)^[+\- ]?\s*(?:export\s+)?([A-Z0-9_]*(?:KEY|SECRET|TOKEN|PASSWORD|PASSWD|CREDENTIALS?)[A-Z0-9_]*)\s*[=:]\s*["']?([^\s"']+)`),
+}
+
+// highEntropyCandidate matches long runs of characters that random tokens are made of.
+var highEntropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+// minSecretEntropy is the Shannon entropy in bits per character above which a long token is
+// treated as a secret. Hex strings such as commit hashes stay below it at 4 bits per character.
+const minSecretEntropy = 4.5
+
+// settingName matches the names of key, secret, token or password assignments that configure
+// them rather than hold them, like MAX_TOKENS or DB_PASSWORD_LENGTH.
+var settingName = regexp.MustCompile(`^(?:MAX|MIN)_|_(?:TOKENS|TTL|LENGTH|LEN|SIZE|COUNT|LIMIT|TIMEOUT|EXPIRY|RETRIES)$`)
+
+// nonSecretValues are assigned values that are settings rather than secrets, like USE_API_KEY=false.
+var nonSecretValues = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "null": true, "none": true,
+}
+
+// isSettingAssignment reports whether the assignment of value to name configures a secret instead
+// of holding one. Every other value is redacted, however short or numeric.
+func isSettingAssignment(name, value string) bool {
+	return settingName.MatchString(name) || nonSecretValues[strings.ToLower(value)]
+}
+
+// redactText replaces secrets in text with redactedPlaceholder and returns the number of redactions.
+func redactText(text string) (string, int) {
+	count := 0
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if pattern.NumSubexp() == 0 {
+				count++
+				return redactedPlaceholder
+			}
+			groups := pattern.FindStringSubmatch(match)
+			value := groups[len(groups)-1]
+			if value == redactedPlaceholder || len(groups) > 2 && isSettingAssignment(groups[1], value) {
+				return match
+			}
+			count++
+			return strings.Replace(match, value, redactedPlaceholder, 1)
+		})
+	}
+
+	text = highEntropyCandidate.ReplaceAllStringFunc(text, func(match string) string {
+		if shannonEntropy(match) < minSecretEntropy {
+			return match
+		}
+		count++
+		return redactedPlaceholder
+	})
+	return text, count
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,117 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultRenameThreshold is the similarity in percent git needs to pair a deleted and an added
+// file as a rename, the same as git's own default.
+const defaultRenameThreshold = 50
+
+// maxListedRenames caps the renames listed in the changes overview.
+const maxListedRenames = 20
+
+// renameOptions returns the git diff options detecting renames at threshold percent similarity,
+// and copies too with findCopies. A threshold of 0 turns rename detection off, whatever the
+// diff.renames setting of the repository.
+func renameOptions(threshold int, findCopies bool) ([]string, error) {
+	if threshold < 0 || threshold > 100 {
+		return nil, fmt.Errorf("-find-renames must be between 0 and 100")
+	}
+	if threshold == 0 {
+		if findCopies {
+			return nil, fmt.Errorf("-find-copies needs rename detection, -find-renames must not be 0")
+		}
+		return []string{"--no-renames"}, nil
+	}
+	options := []string{fmt.Sprintf("-M%d%%", threshold)}
+	if findCopies {
+		options = append(options, fmt.Sprintf("-C%d%%", threshold))
+	}
+	return options, nil
+}
+
+// fileMove is a file renamed or copied in a diff.
+type fileMove struct {
+	from, to string
+	copied   bool
+}
+
+// diffMoves lists the renames and copies in the extended headers of a git diff, once each.
+func diffMoves(diff string) []fileMove {
+	var moves []fileMove
+	seen := map[fileMove]bool{}
+	for _, chunk := range splitDiffByFile(diff) {
+		if !strings.HasPrefix(chunk, "diff --git ") {
+			continue
+		}
+		var move fileMove
+		for _, line := range strings.Split(chunk, "\n") {
+			if strings.HasPrefix(line, "@@") {
+				break
+			}
+			switch {
+			case strings.HasPrefix(line, "rename from "):
+				move.from = strings.TrimPrefix(line, "rename from ")
+			case strings.HasPrefix(line, "rename to "):
+				move.to = strings.TrimPrefix(line, "rename to ")
+			case strings.HasPrefix(line, "copy from "):
+				move.from, move.copied = strings.TrimPrefix(line, "copy from "), true
+			case strings.HasPrefix(line, "copy to "):
+				move.to = strings.TrimPrefix(line, "copy to ")
+			}
+		}
+		if move.from != "" && move.to != "" && !seen[move] {
+			seen[move] = true
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+// movesOverviewNote is appended to the changes overview to list the renamed and copied files,
+// so the summary provider sees that code moved rather than being rewritten.
+func movesOverviewNote(moves []fileMove) string {
+	var b strings.Builder
+	b.WriteString("\n\nRenamed/Moved files:")
+	for i, move := range moves {
+		if i == maxListedRenames {
+			fmt.Fprintf(&b, "\n (+%d more)", len(moves)-maxListedRenames)
+			break
+		}
+		verb := "→"
+		if move.copied {
+			verb = "→ (copy)"
+		}
+		fmt.Fprintf(&b, "\n %s %s %s", move.from, verb, move.to)
+	}
+	return b.String()
+}
+
+// noteMoves lists the renamed and copied files of the diff of changes in their overview.
+func noteMoves(changes ChangeSet) ChangeSet {
+	if moves := diffMoves(changes.Diff); len(moves) > 0 {
+		logf("Found %d renamed or copied files", len(moves))
+		changes.Overview += movesOverviewNote(moves)
+	}
+	return changes
+}
+
+// renamedPath returns the new path of a git --numstat path, which is "old => new" for a rename,
+// or "dir/{old => new}/file" when the paths share a prefix or suffix.
+func renamedPath(path string) string {
+	if open := strings.Index(path, "{"); open >= 0 {
+		if end := strings.Index(path[open:], "}"); end >= 0 {
+			if _, to, ok := strings.Cut(path[open+1:open+end], " => "); ok {
+				joined := path[:open] + to + path[open+end+1:]
+				// An empty side leaves a doubled slash, e.g. "src/{ => lib}/a.go" with an empty "from"
+				return strings.ReplaceAll(joined, "//", "/")
+			}
+		}
+	}
+	if _, to, ok := strings.Cut(path, " => "); ok {
+		return to
+	}
+	return path
+}
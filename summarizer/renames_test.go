@@ -0,0 +1,57 @@
+package summarizer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenameOptions(t *testing.T) {
+	tests := []struct {
+		threshold int
+		copies    bool
+		want      []string
+		wantErr   bool
+	}{
+		{threshold: 50, want: []string{"-M50%"}},
+		{threshold: 90, copies: true, want: []string{"-M90%", "-C90%"}},
+		{threshold: 0, want: []string{"--no-renames"}},
+		{threshold: 0, copies: true, wantErr: true},
+		{threshold: 101, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := renameOptions(tt.threshold, tt.copies)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("renameOptions(%d, %v) = %q, %v, want %q, error %v", tt.threshold, tt.copies, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDiffMoves(t *testing.T) {
+	diff := "diff --git a/old.go b/pkg/new.go\nsimilarity index 100%\nrename from old.go\nrename to pkg/new.go\n" +
+		"diff --git a/a.go b/b.go\nsimilarity index 80%\ncopy from a.go\ncopy to b.go\n--- a/a.go\n+++ b/b.go\n@@ -1 +1 @@\n-rename from x\n+y\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n"
+	want := []fileMove{{from: "old.go", to: "pkg/new.go"}, {from: "a.go", to: "b.go", copied: true}}
+	if got := diffMoves(diff); !slices.Equal(got, want) {
+		t.Fatalf("diffMoves() = %+v, want %+v", got, want)
+	}
+
+	wantNote := "\n\nRenamed/Moved files:\n old.go → pkg/new.go\n a.go → (copy) b.go"
+	if got := movesOverviewNote(want); got != wantNote {
+		t.Errorf("movesOverviewNote() = %q, want %q", got, wantNote)
+	}
+}
+
+func TestRenamedPath(t *testing.T) {
+	tests := map[string]string{
+		"main.go":                    "main.go",
+		"old.go => new.go":           "new.go",
+		"src/{parser => lexer}/a.go": "src/lexer/a.go",
+		"src/{ => internal}/a.go":    "src/internal/a.go",
+		"{a.go => b.go}":             "b.go",
+	}
+	for path, want := range tests {
+		if got := renamedPath(path); got != want {
+			t.Errorf("renamedPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package summarizer
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ANSI escape sequences used by -render.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// Inline markdown spans styled by -render.
+var (
+	inlineCode = regexp.MustCompile("`([^`]+)`")
+	strongText = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// colorEnabled reports whether -render may style the output: stdout must be a terminal and
+// NO_COLOR (see no-color.org) must be unset or empty.
+func colorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// renderMarkdown styles markdown for a terminal with ANSI escapes: headings in bold (top-level ones
+// underlined as well), bold spans, inline code and fenced code blocks in color, and bullets as dots.
+// Anything else, including HTML comments like the -inject-into markers, is left as it is.
+func renderMarkdown(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	var inFence bool
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			lines[i] = ansiDim + line + ansiReset
+			continue
+		}
+		if inFence {
+			lines[i] = ansiCyan + "    " + line + ansiReset
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			style := ansiBold
+			if len(m[1]) <= 2 {
+				style += ansiUnderline
+			}
+			lines[i] = style + renderInline(m[2], ansiReset+style) + ansiReset
+			continue
+		}
+		line = bulletRe.ReplaceAllString(line, "$1• ")
+		lines[i] = renderInline(line, ansiReset)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderInline styles the bold spans and inline code of a line. restore is the sequence that
+// returns to the style of the surrounding text after a span.
+func renderInline(line, restore string) string {
+	line = inlineCode.ReplaceAllString(line, ansiCyan+"$1"+restore)
+	return strongText.ReplaceAllString(line, ansiBold+"$1$2"+restore)
+}
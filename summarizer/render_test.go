@@ -0,0 +1,34 @@
+package summarizer
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "top-level heading", markdown: "## Summary", want: ansiBold + ansiUnderline + "Summary" + ansiReset},
+		{name: "sub heading", markdown: "### Details ###", want: ansiBold + "Details" + ansiReset},
+		{name: "heading with code", markdown: "### The `run` loop", want: ansiBold + "The " + ansiCyan + "run" + ansiReset + ansiBold + " loop" + ansiReset},
+		{name: "bullet with bold", markdown: "  - **Fix** the parser", want: "  • " + ansiBold + "Fix" + ansiReset + " the parser"},
+		{name: "inline code", markdown: "Calls `getSummary` once", want: "Calls " + ansiCyan + "getSummary" + ansiReset + " once"},
+		{name: "fenced code", markdown: "```go\nx := **y**\n```", want: ansiDim + "```go" + ansiReset + "\n" + ansiCyan + "    x := **y**" + ansiReset + "\n" + ansiDim + "```" + ansiReset},
+		{name: "plain text", markdown: "Nothing to style\n<!-- prgpt:summary -->", want: "Nothing to style\n<!-- prgpt:summary -->"},
+		{name: "not a heading", markdown: "#hashtag", want: "#hashtag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.markdown); got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorEnabledNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled() {
+		t.Error("colorEnabled() = true with NO_COLOR set")
+	}
+}
@@ -0,0 +1,65 @@
+package summarizer
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// optionalFloat is a flag.Value for a number that is only used when it was set, so the
+// provider's own default applies otherwise.
+type optionalFloat struct {
+	value float64
+	set   bool
+}
+
+func (f *optionalFloat) String() string {
+	if !f.set {
+		return ""
+	}
+	return strconv.FormatFloat(f.value, 'g', -1, 64)
+}
+
+func (f *optionalFloat) Set(value string) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	f.value, f.set = v, true
+	return nil
+}
+
+// Sampling parameters of the summary model, sent only when set with -temperature and -top-p.
+var temperature, topP optionalFloat
+
+// maxTemperature returns the highest temperature the API of provider accepts.
+func maxTemperature(provider string) float64 {
+	switch provider {
+	case "openai", "azure", "gemini", "ollama":
+		return 2
+	default:
+		// Anthropic, also on Bedrock
+		return 1
+	}
+}
+
+// validateSampling checks -temperature and -top-p against the ranges the provider accepts.
+func validateSampling(provider string) error {
+	if max := maxTemperature(provider); temperature.set && (temperature.value < 0 || temperature.value > max) {
+		return fmt.Errorf("-temperature must be between 0 and %g with -provider %s", max, provider)
+	}
+	if topP.set && (topP.value < 0 || topP.value > 1) {
+		return fmt.Errorf("-top-p must be between 0 and 1")
+	}
+	return nil
+}
+
+// addSamplingParams adds the sampling parameters that were set to a request body, naming them
+// temperatureKey and topPKey as the provider's API does.
+func addSamplingParams(body map[string]interface{}, temperatureKey, topPKey string) {
+	if temperature.set {
+		body[temperatureKey] = temperature.value
+	}
+	if topP.set {
+		body[topPKey] = topP.value
+	}
+}
@@ -0,0 +1,66 @@
+package summarizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSamplingParams(t *testing.T) {
+	original := [2]optionalFloat{temperature, topP}
+	defer func() { temperature, topP = original[0], original[1] }()
+	provider := &anthropicProvider{model: anthropicModel, maxTokens: anthropicMaxTokens}
+
+	temperature, topP = optionalFloat{}, optionalFloat{}
+	body, err := provider.requestBody("prompt", false)
+	if err != nil {
+		t.Fatalf("requestBody() error = %v", err)
+	}
+	if strings.Contains(string(body), "temperature") || strings.Contains(string(body), "top_p") {
+		t.Errorf("requestBody() without -temperature and -top-p = %s, want neither", body)
+	}
+
+	if err := temperature.Set("0"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := topP.Set("0.9"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	body, err = provider.requestBody("prompt", false)
+	if err != nil {
+		t.Fatalf("requestBody() error = %v", err)
+	}
+	if !strings.Contains(string(body), `"temperature":0`) || !strings.Contains(string(body), `"top_p":0.9`) {
+		t.Errorf("requestBody() = %s, want temperature 0 and top_p 0.9", body)
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	original := [2]optionalFloat{temperature, topP}
+	defer func() { temperature, topP = original[0], original[1] }()
+
+	tests := []struct {
+		provider    string
+		temperature optionalFloat
+		topP        optionalFloat
+		wantErr     bool
+	}{
+		{provider: "anthropic"},
+		{provider: "anthropic", temperature: optionalFloat{value: 1, set: true}, topP: optionalFloat{value: 0, set: true}},
+		{provider: "anthropic", temperature: optionalFloat{value: 1.5, set: true}, wantErr: true},
+		{provider: "openai", temperature: optionalFloat{value: 1.5, set: true}},
+		{provider: "openai", temperature: optionalFloat{value: -0.1, set: true}, wantErr: true},
+		{provider: "gemini", topP: optionalFloat{value: 1.1, set: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		temperature, topP = tt.temperature, tt.topP
+		if err := validateSampling(tt.provider); (err != nil) != tt.wantErr {
+			t.Errorf("validateSampling(%q) with temperature %s, top_p %s: error = %v, want error %v",
+				tt.provider, temperature.String(), topP.String(), err, tt.wantErr)
+		}
+	}
+
+	var f optionalFloat
+	if err := f.Set("warm"); err == nil {
+		t.Error(`Set("warm") succeeded, want an error`)
+	}
+}
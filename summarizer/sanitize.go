@@ -0,0 +1,60 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// sanitizeText makes command output and diffs safe to embed in prompts, templates and the terminal.
+// Invalid UTF-8 bytes, as in old commits written in another encoding, become U+FFFD, and control
+// characters other than newline and tab are escaped as \xNN (or \u00NN for C1 controls), so they
+// can't garble the terminal. A carriage return before a newline is dropped, leaving CRLF files readable.
+func sanitizeText(s string) string {
+	if !needsSanitizing(s) {
+		return s
+	}
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\r\n", "\n")
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r >= 0x80 && r <= 0x9f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// needsSanitizing reports whether s has invalid UTF-8 or control characters, so clean text,
+// the common case, is returned without copying.
+func needsSanitizing(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f || r >= 0x80 && r <= 0x9f {
+			return true
+		}
+	}
+	return false
+}
+
+// runeBoundary returns the largest index of s that is at most n and doesn't split a UTF-8 character,
+// so s[:runeBoundary(s, n)] is a valid prefix of at most n bytes.
+func runeBoundary(s string, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
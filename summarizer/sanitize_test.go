@@ -0,0 +1,59 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "clean", in: "Fix parser\n\tindent", want: "Fix parser\n\tindent"},
+		{name: "latin-1 bytes", in: "Caf\xe9 menu", want: "Caf� menu"},
+		{name: "truncated sequence", in: "ab\xe2\x82", want: "ab�"},
+		{name: "escape sequence", in: "+\x1b[31mred\x1b[0m", want: `+\x1b[31mred\x1b[0m`},
+		{name: "NUL and bell", in: "a\x00b\x07", want: `a\x00b\x07`},
+		{name: "CRLF", in: "line one\r\nline two\r\n", want: "line one\nline two\n"},
+		{name: "lone carriage return", in: "50%\r100%", want: `50%\x0d100%`},
+		{name: "DEL and C1 control", in: "a\x7fb\u0085c", want: `a\x7fb\u0085c`},
+		{name: "unicode kept", in: "naïve → ✓", want: "naïve → ✓"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeText(tt.in)
+			if got != tt.want {
+				t.Errorf("sanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("sanitizeText(%q) = %q is not valid UTF-8", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestGitOutputSanitized(t *testing.T) {
+	fakeCommands(t, map[string]string{
+		"git log --oneline": "abc1234 Caf\xe9 \x1b[2Jfix",
+	})
+	output, err := getCommandOutput("git", "log", "--oneline")
+	if err != nil {
+		t.Fatalf("getCommandOutput() error = %v", err)
+	}
+	if want := `abc1234 Caf` + "�" + ` \x1b[2Jfix`; output != want {
+		t.Errorf("getCommandOutput() = %q, want %q", output, want)
+	}
+
+	// The sanitized text survives a JSON round trip unchanged, as in an API request body
+	body, err := json.Marshal(map[string]string{"content": output})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded["content"] != output {
+		t.Errorf("JSON round trip = %q, %v, want %q", decoded["content"], err, output)
+	}
+}
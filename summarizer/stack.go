@@ -0,0 +1,211 @@
+package summarizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// skipStackHint leaves the detected languages and frameworks out of the system prompt.
+var skipStackHint bool
+
+// stackExtensions are -stack-ext entries such as ".vue=Vue" that add to or override languageExtensions.
+var stackExtensions stringListFlag
+
+// languageExtensions maps file extensions to the language they are written in.
+var languageExtensions = map[string]string{
+	".go":    "Go",
+	".rs":    "Rust",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".scala": "Scala",
+	".cs":    "C#",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".swift": "Swift",
+	".php":   "PHP",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".sh":    "shell",
+	".sql":   "SQL",
+	".tf":    "Terraform",
+}
+
+// stackFiles maps key files at the repository root to the build tool or ecosystem they indicate.
+var stackFiles = []struct {
+	file  string
+	stack string
+}{
+	{"go.mod", "Go modules"},
+	{"Cargo.toml", "Cargo"},
+	{"package.json", "npm"},
+	{"pyproject.toml", "a pyproject.toml build"},
+	{"requirements.txt", "pip requirements"},
+	{"Gemfile", "Bundler"},
+	{"pom.xml", "Maven"},
+	{"build.gradle", "Gradle"},
+	{"build.gradle.kts", "Gradle"},
+	{"composer.json", "Composer"},
+	{"mix.exs", "Mix"},
+}
+
+// packageFrameworks are npm dependencies worth naming as the framework of a project.
+var packageFrameworks = map[string]string{
+	"react":         "React",
+	"next":          "Next.js",
+	"vue":           "Vue",
+	"@angular/core": "Angular",
+	"svelte":        "Svelte",
+	"express":       "Express",
+}
+
+// minLanguageShare is the share of the changed lines a language needs to count as dominant.
+const minLanguageShare = 0.2
+
+// extensionLanguages returns languageExtensions with the -stack-ext entries applied.
+func extensionLanguages() (map[string]string, error) {
+	languages := make(map[string]string, len(languageExtensions)+len(stackExtensions))
+	for ext, language := range languageExtensions {
+		languages[ext] = language
+	}
+	for _, entry := range stackExtensions {
+		ext, language, ok := strings.Cut(entry, "=")
+		ext, language = strings.TrimSpace(ext), strings.TrimSpace(language)
+		if !ok || !strings.HasPrefix(ext, ".") || language == "" {
+			return nil, fmt.Errorf("%q is not an .ext=Language entry", entry)
+		}
+		languages[strings.ToLower(ext)] = language
+	}
+	return languages, nil
+}
+
+// dominantLanguages returns the languages with at least minLanguageShare of the changed lines,
+// most changed first. Binary files and unknown extensions are left out.
+func dominantLanguages(files []FileStat, languages map[string]string) []string {
+	lines := map[string]int{}
+	var total int
+	for _, file := range files {
+		language, ok := languages[strings.ToLower(path.Ext(file.Path))]
+		if !ok || file.Binary {
+			continue
+		}
+		// A file whose only change is a rename still counts a little
+		changed := max(file.Added+file.Deleted, 1)
+		lines[language] += changed
+		total += changed
+	}
+
+	var dominant []string
+	for language, n := range lines {
+		if float64(n) >= minLanguageShare*float64(total) {
+			dominant = append(dominant, language)
+		}
+	}
+	sort.Slice(dominant, func(i, j int) bool {
+		if lines[dominant[i]] != lines[dominant[j]] {
+			return lines[dominant[i]] > lines[dominant[j]]
+		}
+		return dominant[i] < dominant[j]
+	})
+	return dominant
+}
+
+// projectStack describes the build tools and frameworks found in the key files at repoRoot.
+func projectStack(repoRoot string) []string {
+	if repoRoot == "" {
+		return nil
+	}
+	var stack []string
+	for _, f := range stackFiles {
+		content, err := os.ReadFile(filepath.Join(repoRoot, f.file))
+		if err != nil {
+			continue
+		}
+		switch f.file {
+		case "go.mod":
+			if !strings.Contains(string(content), "\nrequire") {
+				stack = append(stack, "Go modules with only the standard library")
+				continue
+			}
+		case "package.json":
+			stack = append(stack, packageJSONFrameworks(content)...)
+		}
+		if !slices.Contains(stack, f.stack) {
+			stack = append(stack, f.stack)
+		}
+	}
+	return stack
+}
+
+// packageJSONFrameworks returns the packageFrameworks a package.json depends on, in alphabetical order.
+func packageJSONFrameworks(content []byte) []string {
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(content, &manifest) != nil {
+		return nil
+	}
+	var frameworks []string
+	for dependency, framework := range packageFrameworks {
+		_, dep := manifest.Dependencies[dependency]
+		_, devDep := manifest.DevDependencies[dependency]
+		if dep || devDep {
+			frameworks = append(frameworks, framework)
+		}
+	}
+	sort.Strings(frameworks)
+	return frameworks
+}
+
+// stackHint is the sentence about the languages and stack added to the system prompt, or "" when
+// nothing was detected, e.g. "The changes are mostly in Go. The project uses Go modules with only the standard library."
+func stackHint(languages, stack []string) string {
+	var sentences []string
+	if len(languages) > 0 {
+		sentences = append(sentences, "The changes are mostly in "+joinAnd(languages)+".")
+	}
+	if len(stack) > 0 {
+		sentences = append(sentences, "The project uses "+joinAnd(stack)+".")
+	}
+	return strings.Join(sentences, " ")
+}
+
+// joinAnd joins items as English prose, e.g. "Go, Rust and SQL".
+func joinAnd(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// addStackHint appends the hint about the languages of the changed files and the project stack
+// to the system prompt, unless -no-stack-hint is set.
+func addStackHint(repoRoot string, files []FileStat) {
+	if skipStackHint {
+		return
+	}
+	// The -stack-ext entries were already checked by validateSettings
+	languages, _ := extensionLanguages()
+	hint := stackHint(dominantLanguages(files, languages), projectStack(repoRoot))
+	if hint == "" {
+		return
+	}
+	logf("Stack hint: %s", hint)
+	appendSystemPrompt(hint)
+}
@@ -0,0 +1,47 @@
+package summarizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDominantLanguages(t *testing.T) {
+	files := []FileStat{
+		{Path: "cmd/main.go", Added: 60, Deleted: 20},
+		{Path: "web/App.vue", Added: 30},
+		{Path: "web/util.ts", Added: 5},
+		{Path: "logo.png", Binary: true},
+		{Path: "README.md", Added: 100},
+	}
+
+	stackExtensions = stringListFlag{".vue=Vue"}
+	t.Cleanup(func() { stackExtensions = nil })
+	languages, err := extensionLanguages()
+	if err != nil {
+		t.Fatalf("extensionLanguages() error = %v", err)
+	}
+
+	got := dominantLanguages(files, languages)
+	if want := []string{"Go", "Vue"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dominantLanguages() = %v, want %v", got, want)
+	}
+}
+
+func TestProjectStack(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/app\n\ngo 1.22\n")
+	write("package.json", `{"dependencies": {"react": "^18.0.0"}, "devDependencies": {"vite": "^5.0.0"}}`)
+
+	stack := projectStack(root)
+	want := "The changes are mostly in Go. The project uses Go modules with only the standard library, React and npm."
+	if got := stackHint([]string{"Go"}, stack); got != want {
+		t.Errorf("stackHint() = %q, want %q", got, want)
+	}
+}
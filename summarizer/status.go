@@ -0,0 +1,85 @@
+package summarizer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusInterval is how often the dots after the current phase are updated.
+const statusInterval = 400 * time.Millisecond
+
+// statusLine shows the current phase of a long operation on stderr, followed by a ticking row of dots.
+// It is only drawn when stderr is a terminal, without -quiet and without verbose output interleaved with it.
+type statusLine struct {
+	mu    sync.Mutex
+	phase string
+	dots  int
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// status is the status line of the running command.
+var status = &statusLine{}
+
+// stderrIsTerminal reports whether stderr is attached to a terminal rather than a pipe or file.
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// set shows phase as the current status, replacing the previous one.
+func (s *statusLine) set(phase string) {
+	if verbose || quiet || !stderrIsTerminal() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase, s.dots = phase, 3
+	s.draw()
+	if s.stop == nil {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.tick(s.stop, s.done)
+	}
+}
+
+// clear removes the status line so the terminal is left as it was. It is safe to call when nothing is shown.
+func (s *statusLine) clear() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+// tick redraws the status line every statusInterval until stop is closed.
+func (s *statusLine) tick(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.dots = (s.dots + 1) % 4
+			s.draw()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// draw writes the status line. The caller must hold s.mu.
+func (s *statusLine) draw() {
+	fmt.Fprintf(os.Stderr, "\r\033[K%s%s", s.phase, strings.Repeat(".", s.dots))
+}
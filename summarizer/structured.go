@@ -0,0 +1,178 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StructuredSummary is the summary in separate fields, generated with -structured.
+type StructuredSummary struct {
+	Title           string   `json:"title"`
+	Summary         string   `json:"summary"`
+	BreakingChanges []string `json:"breaking_changes"`
+	TestPlan        []string `json:"test_plan"`
+}
+
+// structuredSchemaName names the schema for the providers that need one, as the Anthropic tool name
+// and the OpenAI json_schema name.
+const structuredSchemaName = "submit_summary"
+
+// structuredSchema is the JSON schema of StructuredSummary. Every field is required and no others
+// are allowed, as OpenAI's strict mode demands.
+var structuredSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"title": map[string]string{
+			"type":        "string",
+			"description": "one-line pull request title, at most 72 characters",
+		},
+		"summary": map[string]string{
+			"type":        "string",
+			"description": "the summary of the changes in markdown",
+		},
+		"breaking_changes": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]string{"type": "string"},
+			"description": "changes that break existing users, empty when there are none",
+		},
+		"test_plan": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]string{"type": "string"},
+			"description": "steps to verify the changes",
+		},
+	},
+	"required":             []string{"title", "summary", "breaking_changes", "test_plan"},
+	"additionalProperties": false,
+}
+
+// structuredInstruction is appended to the prompt with -structured, for the providers that can't
+// enforce the schema and as a hint for those that can.
+const structuredInstruction = `
+
+Reply with only a JSON object, without code fences, with these fields:
+- "title": a one-line pull request title of at most 72 characters
+- "summary": the summary of the changes in markdown
+- "breaking_changes": a list of changes that break existing users, empty when there are none
+- "test_plan": a list of steps to verify the changes`
+
+// structuredRepairPrompt asks the model to fix a reply that didn't parse; the placeholders are the
+// error and the reply.
+const structuredRepairPrompt = `
+
+Your previous reply could not be used: %v
+
+Previous reply:
+%s
+
+Reply again with only the corrected JSON object.`
+
+// parseStructuredSummary parses the JSON object in a reply, ignoring code fences and any text
+// around it, and checks that the title and summary are set.
+func parseStructuredSummary(reply string) (*StructuredSummary, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object in reply")
+	}
+	var result StructuredSummary
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	result.Title = strings.TrimSpace(result.Title)
+	result.Summary = strings.TrimSpace(result.Summary)
+	switch {
+	case result.Title == "":
+		return nil, errors.New(`"title" is empty`)
+	case result.Summary == "":
+		return nil, errors.New(`"summary" is empty`)
+	case strings.Contains(result.Title, "\n"):
+		return nil, errors.New(`"title" is not a single line`)
+	}
+	return &result, nil
+}
+
+// markdown renders the summary with its breaking changes and test plan as sections, for the
+// {{.Summary}} of the PR template. The title is left to the template as {{.Title}}.
+func (s *StructuredSummary) markdown() string {
+	var b strings.Builder
+	b.WriteString(s.Summary)
+	writeSection := func(heading string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("\n\n### " + heading + "\n")
+		for _, item := range items {
+			b.WriteString("\n- " + strings.TrimSpace(item))
+		}
+	}
+	writeSection("Breaking Changes", s.BreakingChanges)
+	writeSection("Test Plan", s.TestPlan)
+	return b.String()
+}
+
+// summarizeStructured sends the prompt with the schema enforced if the provider supports it, and
+// with the instructions in the prompt only otherwise.
+func summarizeStructured(ctx context.Context, provider SummaryProvider, prompt string) (string, error) {
+	if structured, ok := provider.(StructuredProvider); ok {
+		return structured.SummarizeStructured(ctx, prompt)
+	}
+	return provider.Summarize(ctx, prompt)
+}
+
+// requestStructuredSummary asks the provider for a structured summary of the prompt and parses it.
+// A reply that doesn't parse is sent back once along with the error for the model to repair.
+func requestStructuredSummary(ctx context.Context, provider SummaryProvider, prompt string) (*StructuredSummary, string, error) {
+	prompt += structuredInstruction
+	reply, err := summarizeStructured(ctx, provider, prompt)
+	if err != nil {
+		return nil, "", err
+	}
+	result, parseErr := parseStructuredSummary(reply)
+	if parseErr == nil {
+		return result, reply, nil
+	}
+
+	warnf("Warning: the structured summary is malformed (%v), asking the model to repair it", parseErr)
+	repaired, err := summarizeStructured(ctx, provider, prompt+fmt.Sprintf(structuredRepairPrompt, parseErr, reply))
+	if err != nil {
+		return nil, "", err
+	}
+	if result, err = parseStructuredSummary(repaired); err != nil {
+		return nil, "", fmt.Errorf("malformed structured summary after repair: %v", err)
+	}
+	return result, repaired, nil
+}
+
+// getStructuredSummary generates a summary of changes in separate fields, like getSummary does for
+// the plain summary. The mock provider fills the fields from its own summary.
+func getStructuredSummary(ctx context.Context, provider SummaryProvider, changes ChangeSet) (*StructuredSummary, error) {
+	if changes.Diff == "" {
+		return nil, ErrEmptyDiff
+	}
+	if offline, ok := provider.(offlineProvider); ok {
+		return &StructuredSummary{
+			Title:           mockTitle(changes),
+			Summary:         offline.summarizeChanges(changes),
+			BreakingChanges: []string{},
+			TestPlan:        []string{},
+		}, nil
+	}
+	defer status.clear()
+	prompt, err := preparePrompt(ctx, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	status.set("Generating structured summary")
+	done := metrics.track(phaseSummary)
+	result, reply, err := requestStructuredSummary(ctx, provider, prompt)
+	done()
+	if err != nil {
+		return nil, fail(exitAPI, "Error generating summary: %w", err)
+	}
+	metrics.addEstimate(prompt, reply)
+	summaryDone(changes)
+	return result, nil
+}
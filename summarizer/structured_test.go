@@ -0,0 +1,164 @@
+package summarizer
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStructuredSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    *StructuredSummary
+		wantErr string
+	}{
+		{
+			name:  "plain JSON",
+			reply: `{"title":"Add X","summary":"Adds X.","breaking_changes":[],"test_plan":["run it"]}`,
+			want:  &StructuredSummary{Title: "Add X", Summary: "Adds X.", BreakingChanges: []string{}, TestPlan: []string{"run it"}},
+		},
+		{
+			name:  "code fence and prose",
+			reply: "Here it is:\n```json\n{\"title\":\" Add X \",\"summary\":\"Adds X.\"}\n```",
+			want:  &StructuredSummary{Title: "Add X", Summary: "Adds X."},
+		},
+		{name: "no object", reply: "Adds X.", wantErr: "no JSON object"},
+		{name: "invalid JSON", reply: `{"title":"Add X",}`, wantErr: "invalid JSON"},
+		{name: "wrong type", reply: `{"title":"Add X","summary":"Adds X.","test_plan":"run it"}`, wantErr: "invalid JSON"},
+		{name: "empty title", reply: `{"title":"","summary":"Adds X."}`, wantErr: `"title" is empty`},
+		{name: "empty summary", reply: `{"title":"Add X"}`, wantErr: `"summary" is empty`},
+		{name: "multi-line title", reply: `{"title":"Add X\nand Y","summary":"Adds X."}`, wantErr: "single line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStructuredSummary(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseStructuredSummary() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStructuredSummary() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseStructuredSummary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredSummaryMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary StructuredSummary
+		want    string
+	}{
+		{name: "summary only", summary: StructuredSummary{Title: "Add X", Summary: "Adds X."}, want: "Adds X."},
+		{
+			name:    "all sections",
+			summary: StructuredSummary{Summary: "Adds X.", BreakingChanges: []string{"drops Y"}, TestPlan: []string{"go test ./...", "run prgpt"}},
+			want:    "Adds X.\n\n### Breaking Changes\n\n- drops Y\n\n### Test Plan\n\n- go test ./...\n- run prgpt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.markdown(); got != tt.want {
+				t.Fatalf("markdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// replyProvider answers each Summarize call with the next of its replies and records the prompts.
+type replyProvider struct {
+	replies []string
+	prompts []string
+}
+
+func (p *replyProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply, nil
+}
+
+func TestRequestStructuredSummaryRepair(t *testing.T) {
+	tests := []struct {
+		name      string
+		replies   []string
+		wantCalls int
+		wantErr   string
+	}{
+		{name: "valid at once", replies: []string{`{"title":"Add X","summary":"Adds X."}`}, wantCalls: 1},
+		{name: "repaired", replies: []string{`{"title":"Add X"`, `{"title":"Add X","summary":"Adds X."}`}, wantCalls: 2},
+		{name: "still malformed", replies: []string{`{"title":"Add X"`, `no JSON`}, wantCalls: 2, wantErr: "after repair"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &replyProvider{replies: tt.replies}
+			got, _, err := requestStructuredSummary(context.Background(), provider, "prompt")
+			if len(provider.prompts) != tt.wantCalls {
+				t.Fatalf("made %d calls, want %d", len(provider.prompts), tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("requestStructuredSummary() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("requestStructuredSummary() error = %v", err)
+			}
+			if got.Title != "Add X" || got.Summary != "Adds X." {
+				t.Fatalf("requestStructuredSummary() = %+v", got)
+			}
+			if tt.wantCalls == 2 && !strings.Contains(provider.prompts[1], tt.replies[0]) {
+				t.Fatalf("repair prompt %q doesn't quote the previous reply", provider.prompts[1])
+			}
+		})
+	}
+}
+
+func TestAnthropicSummarizeStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "tool call",
+			body: `{"type":"message","content":[{"type":"tool_use","name":"submit_summary","input":{"title":"Add X","summary":"Adds X."}}]}`,
+			want: `{"title":"Add X","summary":"Adds X."}`,
+		},
+		{name: "text only", body: `{"type":"message","content":[{"type":"text","text":"Adds X."}]}`, wantErr: "no submit_summary tool call"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPI(t, &anthropicAPIURL, http.StatusOK, tt.body)
+
+			provider := &anthropicProvider{apiKey: "test-key", model: anthropicModel, maxTokens: anthropicMaxTokens}
+			got, err := provider.SummarizeStructured(context.Background(), "prompt")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SummarizeStructured() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SummarizeStructured() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("SummarizeStructured() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
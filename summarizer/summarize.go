@@ -0,0 +1,435 @@
+// Package summarizer generates pull request descriptions from git changes. The prgpt command is a
+// thin wrapper around Main; other Go tools can call Summarize, or GatherChanges, a SummaryProvider
+// and RenderPR separately.
+package summarizer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Options configures Summarize and GatherChanges. The zero value summarizes the commits of the
+// current branch in the working directory with Anthropic, like prgpt does without flags.
+type Options struct {
+	// Dir is the git repository to summarize; empty uses the working directory.
+	Dir string
+	// Base is the ref to compare against; empty uses the first existing base candidate, such as
+	// origin/HEAD or main.
+	Base string
+	// Head is the ref to summarize; empty uses the current branch.
+	Head string
+	// Staged summarizes the changes staged in the index instead of a commit range.
+	Staged bool
+	// Paths limits the summary to changes under these paths, relative to the repository root.
+	Paths []string
+	// Excludes are path globs left out of the summary.
+	Excludes []string
+
+	// Provider names the summary provider: anthropic (the default), openai, azure, gemini, bedrock,
+	// ollama or mock. It is ignored when Summarizer is set.
+	Provider string
+	// Summarizer generates the summary instead of a named Provider.
+	Summarizer SummaryProvider
+	// Model is the model of the provider; empty uses the provider's default model.
+	Model string
+	// APIKey is the API key of the provider; empty reads it from the provider's environment variable,
+	// or the file its <ENV>_FILE variable names.
+	APIKey string
+	// MaxTokens bounds the length of the summary; 0 uses the default.
+	MaxTokens int
+	// SystemPrompt replaces the default system prompt.
+	SystemPrompt string
+	// PRTemplate is a file with a text/template for Result.Markdown; empty uses the repository's
+	// PR template or the built-in one.
+	PRTemplate string
+
+	// OllamaURL is the base URL of the Ollama server; empty uses http://localhost:11434.
+	OllamaURL string
+	// SkipCompression sends the diff without compressing it with Ollama first.
+	SkipCompression bool
+	// SkipEmbeddings leaves the Ollama embeddings out of the prompt.
+	SkipEmbeddings bool
+
+	// Log prints the warnings and progress of prgpt to stderr, which are silenced otherwise.
+	Log bool
+}
+
+// Result is the summary Summarize generated and the changes it summarizes.
+type Result struct {
+	Base    string // ref compared against, empty for staged changes
+	Head    string
+	Changes ChangeSet
+	// Summary is the text generated by the summary provider.
+	Summary string
+	// Markdown is the summary laid out in the PR template along with the commits and overview.
+	Markdown string
+}
+
+// libraryMu serializes the library calls: they apply Options to the package-level settings the
+// prgpt command binds its flags to, and restore them when they return.
+var libraryMu sync.Mutex
+
+// override sets *p to value and appends a function restoring its previous value to restore.
+func override[T any](restore *[]func(), p *T, value T) {
+	previous := *p
+	*p = value
+	*restore = append(*restore, func() { *p = previous })
+}
+
+// applyOptions applies opts to the package-level settings and returns a function restoring them.
+func applyOptions(opts Options) (restore func(), err error) {
+	var undo []func()
+	restore = func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+
+	// Positional arguments of the host program aren't base branches
+	override(&undo, &cmdFlags, flag.NewFlagSet("summarizer", flag.ContinueOnError))
+	override(&undo, &quiet, !opts.Log)
+	override(&undo, &commandRunner, CommandRunner(execRunner{dir: opts.Dir}))
+	override(&undo, &skipCompression, opts.SkipCompression)
+	override(&undo, &skipEmbeddings, opts.SkipEmbeddings)
+	override(&undo, &promptTemplate, promptTemplate)
+	override(&undo, &prTemplate, prTemplate)
+	override(&undo, &systemPrompt, systemPrompt)
+	override(&undo, &anthropicMaxTokens, anthropicMaxTokens)
+	override(&undo, &ollamaURL, ollamaURL)
+	override(&undo, &ollamaAPIURL, ollamaAPIURL)
+	override(&undo, &ollamaCompletionURL, ollamaCompletionURL)
+	// The keys are resolved again for every call, without the -api-key flags of an earlier run
+	override(&undo, &resolvedAPIKeys, map[string]bool{})
+	override(&undo, &apiKeyFlag, "")
+	override(&undo, &apiKeyFileFlag, "")
+
+	if opts.SystemPrompt != "" {
+		systemPrompt = opts.SystemPrompt
+	}
+	if opts.MaxTokens > 0 {
+		anthropicMaxTokens = opts.MaxTokens
+	}
+	if opts.OllamaURL != "" {
+		setOllamaURL(opts.OllamaURL)
+	}
+
+	name := providerName(opts)
+	if opts.Model != "" {
+		model, ok := map[string]*string{
+			"anthropic": &anthropicModel,
+			"openai":    &openAIModel,
+			"azure":     &azureDeployment,
+			"gemini":    &geminiModel,
+			"bedrock":   &bedrockModel,
+			"ollama":    &ollamaSummaryModel,
+		}[name]
+		if !ok {
+			restore()
+			return nil, fmt.Errorf("provider %s has no model to set", name)
+		}
+		override(&undo, model, opts.Model)
+	}
+	if opts.APIKey != "" {
+		key := apiKeyVar(name)
+		if key == nil {
+			restore()
+			return nil, fmt.Errorf("provider %s doesn't use an API key", name)
+		}
+		override(&undo, key, opts.APIKey)
+		// An explicit key wins over the <ENV>_FILE variable
+		resolvedAPIKeys[name] = true
+	}
+	// The mock provider works without any API, Ollama included
+	if name == "mock" {
+		skipCompression, skipEmbeddings = true, true
+	}
+	return restore, nil
+}
+
+// providerName returns the name of the provider opts select.
+func providerName(opts Options) string {
+	switch {
+	case opts.Summarizer != nil:
+		return "custom"
+	case opts.Provider == "":
+		return "anthropic"
+	}
+	return opts.Provider
+}
+
+// GatherChanges collects the commits, diff and file statistics Summarize would summarize. It returns
+// ErrEmptyDiff when there are no file changes.
+func GatherChanges(ctx context.Context, opts Options) (ChangeSet, error) {
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+	restore, err := applyOptions(opts)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	defer restore()
+
+	repoRoot, err := getCommandOutput("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ChangeSet{}, fmt.Errorf("not a git repository: %v", err)
+	}
+	gathered, err := gatherLibraryChanges(ctx, repoRoot, opts)
+	return gathered.changes, err
+}
+
+// Summarize gathers the changes opts select and generates their summary. It returns ErrEmptyDiff
+// when there are no file changes. Calls are serialized, since they share the settings of the
+// prgpt command.
+func Summarize(ctx context.Context, opts Options) (*Result, error) {
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+	restore, err := applyOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	repoRoot, err := getCommandOutput("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %v", err)
+	}
+	o := &options{providerName: providerName(opts), prTemplatePath: opts.PRTemplate}
+	provider := opts.Summarizer
+	if provider == nil {
+		if provider, err = newProvider(o.providerName); err != nil {
+			return nil, err
+		}
+		if err := checkCredentials(o.providerName); err != nil {
+			return nil, err
+		}
+	}
+	if err := prepareRun(ctx, o, repoRoot); err != nil {
+		return nil, err
+	}
+
+	gathered, err := gatherLibraryChanges(ctx, repoRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+	changes := gathered.changes
+	addStackHint(repoRoot, changes.Files)
+
+	summary, err := getSummary(ctx, provider, changes, nil)
+	if err != nil {
+		return nil, err
+	}
+	markdown, err := RenderPR(PRData{Branch: gathered.head, Commits: changes.Commits, Merges: gathered.merges, Overview: changes.Overview, Summary: summary})
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Base: gathered.base, Head: gathered.head, Changes: changes, Summary: summary, Markdown: markdown}, nil
+}
+
+// RenderPR lays out data in the PR template: the one Summarize loaded for the repository, or the
+// built-in one outside of it.
+func RenderPR(data PRData) (string, error) {
+	return renderPRSummary(data)
+}
+
+// NewProvider returns the summary provider with the given name, configured from the environment
+// like prgpt does without flags.
+func NewProvider(name string) (SummaryProvider, error) {
+	return newProvider(name)
+}
+
+// gatherLibraryChanges collects the changes opts select with the default diff options of the pr
+// command. It returns ErrEmptyDiff when there are no file changes.
+func gatherLibraryChanges(ctx context.Context, repoRoot string, opts Options) (gatheredChanges, error) {
+	diffArgs, err := diffOptions("", false, defaultContextLines)
+	if err != nil {
+		return gatheredChanges{}, err
+	}
+	renameArgs, err := renameOptions(defaultRenameThreshold, false)
+	if err != nil {
+		return gatheredChanges{}, err
+	}
+	gathered, err := gatherChanges(ctx, repoRoot, changeRequest{
+		base:       opts.Base,
+		head:       opts.Head,
+		staged:     opts.Staged,
+		paths:      opts.Paths,
+		excludes:   opts.Excludes,
+		allowEmpty: true,
+		diffArgs:   append(diffArgs, renameArgs...),
+		renameArgs: renameArgs,
+	})
+	if err == nil && gathered.changes.Diff == "" {
+		err = ErrEmptyDiff
+	}
+	return gathered, err
+}
+
+// changeRequest selects the changes gatherChanges collects, like the range flags of the pr command.
+// The flag combinations are validated by the caller.
+type changeRequest struct {
+	base, head, since string
+	staged, working   bool
+	paths, excludes   []string
+	noMerges, fetch   bool
+	// allowEmpty gathers a commit range without commits, or no uncommitted changes, instead of failing
+	allowEmpty bool
+	// diffArgs are the options of the diff, such as -diff-algorithm; renameArgs are the rename
+	// detection options among them, which the overview and file list use too
+	diffArgs, renameArgs []string
+}
+
+// gatheredChanges are the changes gatherChanges collected and the refs they compare.
+type gatheredChanges struct {
+	changes ChangeSet
+	// base is empty for uncommitted changes and -since
+	base, head string
+	// merges notes the merge commits of the range for the PR template
+	merges string
+	// pathArgs are the pathspecs the changes are limited to, the .prgptignore files included
+	pathArgs []string
+}
+
+// gatherChanges collects the commits, diff and file statistics req selects: a base..head range, the
+// commits since a date, or uncommitted changes. ChangeSet.Commits lists all commits of the range.
+func gatherChanges(ctx context.Context, repoRoot string, req changeRequest) (gatheredChanges, error) {
+	g := gatheredChanges{head: req.head}
+	var err error
+	if g.head == "" {
+		if g.head, err = getCommandOutput("git", "rev-parse", "--abbrev-ref", "HEAD"); err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error detecting current branch: %v", err)
+		}
+	}
+
+	// pathArgs limits the diff, stat overview and commit list to -path and leaves out -exclude
+	pathArgs := pathspecArgs(req.paths, req.excludes)
+
+	// diffArgs selects what is compared: the base..head commit range, or uncommitted changes.
+	// logArgs selects the commits of a range for git log, and stays empty for uncommitted changes.
+	var diffArgs, logArgs []string
+	logOptions := []string{"--pretty=format:%h - %s"}
+	if req.noMerges {
+		logOptions = append(logOptions, "--no-merges")
+	}
+	var commits, timeRange string
+	switch {
+	case req.since != "":
+		if err := verifyRef(g.head); err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error: %v", err)
+		}
+		sinceBase, err := sinceRange(req.since, g.head)
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error listing commits since %s: %v", req.since, err)
+		}
+		if sinceBase == "" {
+			return gatheredChanges{}, fail(exitFailure, "No commits found on %s since %s", g.head, req.since)
+		}
+		diffArgs = []string{sinceBase, g.head}
+		logArgs = []string{"--since=" + req.since, g.head}
+		timeRange = "since " + req.since
+
+		commits, err = getCommandOutput("git", append(append([]string{"log"}, logOptions...), append(logArgs, pathArgs...)...)...)
+		if err != nil {
+			warnf("Warning: could not list commits: %v", err)
+		}
+	case req.staged:
+		diffArgs = []string{"--cached"}
+		commits = "(uncommitted changes staged in the index)"
+	case req.working:
+		diffArgs = []string{"HEAD"}
+		commits = "(uncommitted changes in the working tree)"
+	default:
+		if g.base, err = resolveBaseBranch(req.base); err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error detecting base branch: %v", err)
+		}
+		g.base = refreshBase(g.base, req.fetch)
+
+		for _, ref := range []string{g.base, g.head} {
+			if err := verifyRef(ref); err != nil {
+				return gatheredChanges{}, fail(exitFailure, "Error: %v", err)
+			}
+		}
+		diffArgs = []string{fmt.Sprintf("%s..%s", g.base, g.head)}
+		logArgs = diffArgs
+
+		// A failing git log just means there are no commits to list
+		commits, err = getCommandOutput("git", append(append([]string{"log"}, logOptions...), append(logArgs, pathArgs...)...)...)
+		if err != nil {
+			warnf("Warning: could not list commits: %v", err)
+		}
+		if commits == "" && !req.allowEmpty {
+			return gatheredChanges{}, fail(exitFailure, "No commits found between %s and %s", g.base, g.head)
+		}
+	}
+
+	// The .prgptignore patterns add to -exclude, as exact paths since gitignore syntax isn't a pathspec
+	ignoreRules, err := loadIgnoreFile(repoRoot)
+	if err != nil {
+		return gatheredChanges{}, fail(exitConfig, "Error reading %s: %v", ignoreFileName, err)
+	}
+	if len(ignoreRules) > 0 {
+		names, err := getCommandOutput("git", append(append(append([]string{"diff", "--name-only"}, req.renameArgs...), diffArgs...), pathArgs...)...)
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error listing changed files: %v", err)
+		}
+		if ignored := ignoredPathspecs(ignoreRules, strings.Split(names, "\n")); len(ignored) > 0 {
+			logf("Leaving out %d files matched by %s", len(ignored), ignoreFileName)
+			if len(pathArgs) == 0 {
+				pathArgs = []string{"--"}
+			}
+			pathArgs = append(pathArgs, ignored...)
+		}
+	}
+	g.pathArgs = pathArgs
+
+	if len(logArgs) > 0 {
+		g.merges = mergeNote(countMerges(logArgs, pathArgs), req.noMerges)
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return gatheredChanges{}, err
+	}
+
+	diffArgs = append(diffArgs, pathArgs...)
+
+	status.set("Gathering diff")
+	var diff, overview string
+	var files []FileStat
+	if req.noMerges && len(logArgs) > 0 {
+		diff, files, err = nonMergeDiff(req.diffArgs, append(logArgs, pathArgs...))
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error getting diff: %v", err)
+		}
+		overview = diffOverview(files)
+	} else {
+		// Without --binary or --text git never prints binary content, and --no-ext-diff keeps external diff drivers out
+		diff, err = getCommandOutput("git", append(append([]string{"diff", "--no-ext-diff"}, req.diffArgs...), diffArgs...)...)
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error getting diff: %v", err)
+		}
+
+		overview, err = getCommandOutput("git", append(append([]string{"diff", "--stat"}, req.renameArgs...), diffArgs...)...)
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error getting diff overview: %v", err)
+		}
+
+		numstat, err := getCommandOutput("git", append(append([]string{"diff", "--numstat"}, req.renameArgs...), diffArgs...)...)
+		if err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error getting diff statistics: %v", err)
+		}
+		if files, err = parseNumstat(numstat); err != nil {
+			return gatheredChanges{}, fail(exitFailure, "Error parsing diff statistics: %v", err)
+		}
+	}
+	if diff == "" && (req.staged || req.working) && !req.allowEmpty {
+		return gatheredChanges{}, fail(exitFailure, "No uncommitted changes found")
+	}
+
+	// The moves go into the overview of the template as well as the prompt
+	if moves := diffMoves(diff); len(moves) > 0 {
+		logf("Found %d renamed or copied files", len(moves))
+		overview += movesOverviewNote(moves)
+	}
+	g.changes = limitChangedLines(collapseBinaryFiles(ChangeSet{Commits: commits, Diff: diff, Overview: overview, Files: files, TimeRange: timeRange}))
+	return g, nil
+}
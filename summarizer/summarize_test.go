@@ -0,0 +1,189 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository with a main branch and a feat branch adding parser.go on top of it.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("README.md", "# test\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "Initial commit")
+	git("checkout", "-q", "-b", "feat")
+	write("parser.go", "package parser\n\nfunc Parse() {}\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "Add parser")
+	return dir
+}
+
+// recordingProvider returns summary and records the prompt it was sent.
+type recordingProvider struct {
+	summary string
+	prompt  string
+}
+
+func (p *recordingProvider) Summarize(ctx context.Context, prompt string) (string, error) {
+	p.prompt = prompt
+	return p.summary, nil
+}
+
+func TestSummarize(t *testing.T) {
+	dir := gitRepo(t)
+	provider := &recordingProvider{summary: "Adds a parser."}
+
+	result, err := Summarize(context.Background(), Options{
+		Dir:             dir,
+		Base:            "main",
+		Summarizer:      provider,
+		SkipCompression: true,
+		SkipEmbeddings:  true,
+	})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if result.Base != "main" || result.Head != "feat" {
+		t.Errorf("Summarize() compared %s..%s, want main..feat", result.Base, result.Head)
+	}
+	if result.Summary != "Adds a parser." {
+		t.Errorf("Summary = %q, want %q", result.Summary, "Adds a parser.")
+	}
+	if len(result.Changes.Files) != 1 || result.Changes.Files[0].Path != "parser.go" {
+		t.Errorf("Changes.Files = %+v, want parser.go", result.Changes.Files)
+	}
+	for _, want := range []string{"Adds a parser.", "Add parser"} {
+		if !strings.Contains(result.Markdown, want) {
+			t.Errorf("Markdown = %q, want it to contain %q", result.Markdown, want)
+		}
+	}
+	if !strings.Contains(provider.prompt, "func Parse()") {
+		t.Errorf("prompt = %q, want it to contain the diff", provider.prompt)
+	}
+	if _, ok := commandRunner.(execRunner); !ok || commandRunner.(execRunner).dir != "" {
+		t.Errorf("commandRunner = %#v after Summarize, want it restored", commandRunner)
+	}
+}
+
+func TestSummarizeMock(t *testing.T) {
+	dir := gitRepo(t)
+
+	result, err := Summarize(context.Background(), Options{Dir: dir, Base: "main", Provider: "mock"})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if !strings.Contains(result.Summary, "- Add parser") {
+		t.Errorf("Summary = %q, want the mock summary of the commits", result.Summary)
+	}
+}
+
+func TestGatherChanges(t *testing.T) {
+	dir := gitRepo(t)
+
+	tests := []struct {
+		name    string
+		opts    Options
+		files   int
+		wantErr error
+	}{
+		{name: "branch", opts: Options{Dir: dir, Base: "main"}, files: 1},
+		{name: "excluded", opts: Options{Dir: dir, Base: "main", Excludes: []string{"*.go"}}, wantErr: ErrEmptyDiff},
+		{name: "nothing staged", opts: Options{Dir: dir, Staged: true}, wantErr: ErrEmptyDiff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := GatherChanges(context.Background(), tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GatherChanges() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(changes.Files) != tt.files {
+				t.Errorf("GatherChanges() files = %+v, want %d", changes.Files, tt.files)
+			}
+		})
+	}
+}
+
+func TestApplyOptionsAPIKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("key-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ANTHROPIC_API_KEY_FILE", keyFile)
+	original := anthropicAPIKey
+	t.Cleanup(func() { anthropicAPIKey = original })
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "explicit key", opts: Options{APIKey: "explicit-key"}, want: "explicit-key"},
+		{name: "key file", opts: Options{}, want: "key-from-file"},
+		// The first run must not leave the key resolved for the next one
+		{name: "key file again", opts: Options{}, want: "key-from-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anthropicAPIKey = "key-from-env"
+			restore, err := applyOptions(tt.opts)
+			if err != nil {
+				t.Fatalf("applyOptions() error = %v", err)
+			}
+			defer restore()
+			if err := resolveAPIKey("anthropic"); err != nil {
+				t.Fatalf("resolveAPIKey() error = %v", err)
+			}
+			if anthropicAPIKey != tt.want {
+				t.Errorf("API key = %q, want %q", anthropicAPIKey, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeOptionErrors(t *testing.T) {
+	dir := gitRepo(t)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "unknown provider", opts: Options{Dir: dir, Provider: "nope"}, want: "nope"},
+		{name: "api key without api", opts: Options{Dir: dir, Provider: "ollama", APIKey: "key"}, want: "doesn't use an API key"},
+		{name: "model of custom provider", opts: Options{Dir: dir, Summarizer: &recordingProvider{}, Model: "m"}, want: "no model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Summarize(context.Background(), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Summarize() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package summarizer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAPI starts a test server answering every request with the given status and body,
+// and points *url at it for the duration of the test.
+func fakeAPI(t *testing.T, url *string, status int, body string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	original, originalRetries := *url, maxRetries
+	*url, maxRetries = server.URL, 0
+	t.Cleanup(func() {
+		*url, maxRetries = original, originalRetries
+	})
+}
+
+// fakeRunner is a CommandRunner returning canned outputs keyed by the full command line.
+// Commands without an output fail like a git command exiting with an error.
+type fakeRunner map[string]string
+
+func (f fakeRunner) Run(name string, args ...string) (string, error) {
+	command := strings.Join(append([]string{name}, args...), " ")
+	if output, ok := f[command]; ok {
+		return output, nil
+	}
+	return "", fmt.Errorf("%s failed: exit status 1", command)
+}
+
+// fakeCommands makes getCommandOutput answer from outputs for the duration of the test.
+func fakeCommands(t *testing.T, outputs map[string]string) {
+	t.Helper()
+
+	original := commandRunner
+	commandRunner = fakeRunner(outputs)
+	t.Cleanup(func() {
+		commandRunner = original
+	})
+}
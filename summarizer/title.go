@@ -0,0 +1,49 @@
+package summarizer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// titlePromptTemplate is the compact prompt used with -title-only.
+const titlePromptTemplate = `Here are the Git changes:
+{{if .Compressed}}
+Compressed Changes:
+{{.Compressed}}
+{{end}}
+Original Content Summary:
+{{.Diff}}
+
+Commits:
+{{.Commits}}
+
+Write a single pull request title for these changes in the Conventional Commits style, e.g.
+"feat(parser): support nested lists". Keep it under 72 characters. Reply with the title only.`
+
+// titlePrefix matches labels models tend to put in front of a title, e.g. "Title:" or "PR title -".
+var titlePrefix = regexp.MustCompile(`(?i)^(pr |pull request )?title\s*[:\-]\s*`)
+
+// cleanTitle reduces a model reply to a bare title line: the first non-empty line without
+// markdown heading markers, "Title:" labels and surrounding quotes or backticks.
+func cleanTitle(reply string) string {
+	var title string
+	for _, line := range strings.Split(reply, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			title = line
+			break
+		}
+	}
+
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	title = strings.TrimSpace(strings.Trim(title, "*"))
+	title = titlePrefix.ReplaceAllString(title, "")
+	for len(title) >= 2 {
+		first, last := title[0], title[len(title)-1]
+		if (first == '"' || first == '\'' || first == '`') && first == last {
+			title = strings.TrimSpace(title[1 : len(title)-1])
+			continue
+		}
+		break
+	}
+	return title
+}
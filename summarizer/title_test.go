@@ -0,0 +1,31 @@
+package summarizer
+
+import "testing"
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{name: "bare title", reply: "feat(parser): support nested lists", want: "feat(parser): support nested lists"},
+		{name: "double quotes", reply: `"fix: handle empty diffs"`, want: "fix: handle empty diffs"},
+		{name: "single quotes and backticks", reply: "`'fix: handle empty diffs'`", want: "fix: handle empty diffs"},
+		{name: "title label", reply: "Title: feat: add -title-only", want: "feat: add -title-only"},
+		{name: "PR title label", reply: "PR title - feat: add -title-only", want: "feat: add -title-only"},
+		{name: "label and quotes", reply: `Title: "feat: add -title-only"`, want: "feat: add -title-only"},
+		{name: "markdown heading", reply: "## **feat: add -title-only**", want: "feat: add -title-only"},
+		{name: "first non-empty line", reply: "\n\n  feat: add -title-only  \n\nThis adds a flag.", want: "feat: add -title-only"},
+		{name: "unbalanced quote kept", reply: `fix: quote "strings`, want: `fix: quote "strings`},
+		{name: "title word inside kept", reply: "fix: title case the header", want: "fix: title case the header"},
+		{name: "empty", reply: " \n ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanTitle(tt.reply); got != tt.want {
+				t.Errorf("cleanTitle(%q) = %q, want %q", tt.reply, got, tt.want)
+			}
+		})
+	}
+}